@@ -2,27 +2,87 @@
 // This file is automatically generated. DO NOT EDIT
 import {updater} from '../models';
 import {service} from '../models';
+import {java} from '../models';
+import {config} from '../models';
+import {download} from '../models';
+import {network} from '../models';
+import {logging} from '../models';
+import {hyerrors} from '../models';
+
+export function CancelDownload(arg1:string):Promise<void>;
+
+export function CancelInstall():Promise<void>;
 
 export function CheckUpdate():Promise<updater.Asset>;
 
+export function CleanupUnusedJREs():Promise<java.CleanupResult>;
+
 export function DeleteGame(arg1:string):Promise<void>;
 
 export function DownloadAndLaunch(arg1:string):Promise<void>;
 
+export function ExportSupportBundle():Promise<string>;
+
 export function GetCrashReports():Promise<Array<service.CrashReport>>;
 
+export function GetDebugLogging():Promise<boolean>;
+
+export function GetDownloadSettings():Promise<config.DownloadConfig>;
+
+export function GetDownloads():Promise<Array<download.Job>>;
+
+export function GetErrorCatalog():Promise<Array<hyerrors.CatalogEntry>>;
+
+export function GetInstanceJRE():Promise<string>;
+
 export function GetLauncherVersion():Promise<string>;
 
 export function GetLocalGameVersion(arg1:string):Promise<number>;
 
+export function GetLogDiskUsage():Promise<service.DiskUsage>;
+
+export function GetLogSettings():Promise<logging.Retention>;
+
 export function GetLogs():Promise<string>;
 
 export function GetNick():Promise<string>;
 
+export function GetProxySettings():Promise<network.ProxyConfig>;
+
+export function GetUnusedJREs():Promise<Array<java.JREInfo>>;
+
+export function GetUpdateSettings():Promise<config.UpdateConfig>;
+
+export function MoveDownload(arg1:string,arg2:number):Promise<void>;
+
 export function OpenFolder():Promise<void>;
 
+export function PauseDownload(arg1:string):Promise<void>;
+
+export function ResumeDownload(arg1:string):Promise<void>;
+
+export function RunFixAction(arg1:string):Promise<void>;
+
+export function SetDebugLogging(arg1:boolean):Promise<void>;
+
+export function SetDownloadLimit(arg1:number):Promise<void>;
+
+export function SetDownloadSettings(arg1:config.DownloadConfig):Promise<void>;
+
+export function SetInstanceJRE(arg1:string):Promise<void>;
+
 export function SetLocalGameVersion(arg1:number,arg2:string):Promise<void>;
 
+export function SetLogSettings(arg1:logging.Retention):Promise<void>;
+
 export function SetNick(arg1:string,arg2:string):Promise<void>;
 
+export function SetProxySettings(arg1:network.ProxyConfig):Promise<void>;
+
+export function SetUpdateSettings(arg1:config.UpdateConfig):Promise<void>;
+
+export function TestProxy(arg1:network.ProxyConfig):Promise<void>;
+
 export function Update():Promise<void>;
+
+export function VerifyGameFiles():Promise<void>;
@@ -2,10 +2,22 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelDownload(arg1) {
+  return window['go']['app']['App']['CancelDownload'](arg1);
+}
+
+export function CancelInstall() {
+  return window['go']['app']['App']['CancelInstall']();
+}
+
 export function CheckUpdate() {
   return window['go']['app']['App']['CheckUpdate']();
 }
 
+export function CleanupUnusedJREs() {
+  return window['go']['app']['App']['CleanupUnusedJREs']();
+}
+
 export function DeleteGame(arg1) {
   return window['go']['app']['App']['DeleteGame'](arg1);
 }
@@ -14,10 +26,34 @@ export function DownloadAndLaunch(arg1) {
   return window['go']['app']['App']['DownloadAndLaunch'](arg1);
 }
 
+export function ExportSupportBundle() {
+  return window['go']['app']['App']['ExportSupportBundle']();
+}
+
 export function GetCrashReports() {
   return window['go']['app']['App']['GetCrashReports']();
 }
 
+export function GetDebugLogging() {
+  return window['go']['app']['App']['GetDebugLogging']();
+}
+
+export function GetDownloadSettings() {
+  return window['go']['app']['App']['GetDownloadSettings']();
+}
+
+export function GetDownloads() {
+  return window['go']['app']['App']['GetDownloads']();
+}
+
+export function GetErrorCatalog() {
+  return window['go']['app']['App']['GetErrorCatalog']();
+}
+
+export function GetInstanceJRE() {
+  return window['go']['app']['App']['GetInstanceJRE']();
+}
+
 export function GetLauncherVersion() {
   return window['go']['app']['App']['GetLauncherVersion']();
 }
@@ -26,6 +62,14 @@ export function GetLocalGameVersion(arg1) {
   return window['go']['app']['App']['GetLocalGameVersion'](arg1);
 }
 
+export function GetLogDiskUsage() {
+  return window['go']['app']['App']['GetLogDiskUsage']();
+}
+
+export function GetLogSettings() {
+  return window['go']['app']['App']['GetLogSettings']();
+}
+
 export function GetLogs() {
   return window['go']['app']['App']['GetLogs']();
 }
@@ -34,18 +78,82 @@ export function GetNick() {
   return window['go']['app']['App']['GetNick']();
 }
 
+export function GetProxySettings() {
+  return window['go']['app']['App']['GetProxySettings']();
+}
+
+export function GetUnusedJREs() {
+  return window['go']['app']['App']['GetUnusedJREs']();
+}
+
+export function GetUpdateSettings() {
+  return window['go']['app']['App']['GetUpdateSettings']();
+}
+
+export function MoveDownload(arg1, arg2) {
+  return window['go']['app']['App']['MoveDownload'](arg1, arg2);
+}
+
 export function OpenFolder() {
   return window['go']['app']['App']['OpenFolder']();
 }
 
+export function PauseDownload(arg1) {
+  return window['go']['app']['App']['PauseDownload'](arg1);
+}
+
+export function ResumeDownload(arg1) {
+  return window['go']['app']['App']['ResumeDownload'](arg1);
+}
+
+export function RunFixAction(arg1) {
+  return window['go']['app']['App']['RunFixAction'](arg1);
+}
+
+export function SetDebugLogging(arg1) {
+  return window['go']['app']['App']['SetDebugLogging'](arg1);
+}
+
+export function SetDownloadLimit(arg1) {
+  return window['go']['app']['App']['SetDownloadLimit'](arg1);
+}
+
+export function SetDownloadSettings(arg1) {
+  return window['go']['app']['App']['SetDownloadSettings'](arg1);
+}
+
+export function SetInstanceJRE(arg1) {
+  return window['go']['app']['App']['SetInstanceJRE'](arg1);
+}
+
 export function SetLocalGameVersion(arg1, arg2) {
   return window['go']['app']['App']['SetLocalGameVersion'](arg1, arg2);
 }
 
+export function SetLogSettings(arg1) {
+  return window['go']['app']['App']['SetLogSettings'](arg1);
+}
+
 export function SetNick(arg1, arg2) {
   return window['go']['app']['App']['SetNick'](arg1, arg2);
 }
 
+export function SetProxySettings(arg1) {
+  return window['go']['app']['App']['SetProxySettings'](arg1);
+}
+
+export function SetUpdateSettings(arg1) {
+  return window['go']['app']['App']['SetUpdateSettings'](arg1);
+}
+
+export function TestProxy(arg1) {
+  return window['go']['app']['App']['TestProxy'](arg1);
+}
+
 export function Update() {
   return window['go']['app']['App']['Update']();
 }
+
+export function VerifyGameFiles() {
+  return window['go']['app']['App']['VerifyGameFiles']();
+}
@@ -1,5 +1,139 @@
+export namespace config {
+	
+	export class DownloadConfig {
+	    limitKB: number;
+	    window: string;
+	    preDownload: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DownloadConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.limitKB = source["limitKB"];
+	        this.window = source["window"];
+	        this.preDownload = source["preDownload"];
+	    }
+	}
+	export class UpdateConfig {
+	    channel: string;
+	    githubToken: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.githubToken = source["githubToken"];
+	    }
+	}
+
+}
+
+export namespace download {
+	
+	export class Expected {
+	    size: number;
+	    sha256: string;
+	    sha1: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Expected(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.size = source["size"];
+	        this.sha256 = source["sha256"];
+	        this.sha1 = source["sha1"];
+	    }
+	}
+	export class Job {
+	    id: string;
+	    kind: string;
+	    name: string;
+	    url: string;
+	    dest: string;
+	    expected: Expected;
+	    state: string;
+	    error?: string;
+	    downloaded: number;
+	    total: number;
+	    window?: string;
+	    // Go type: time
+	    added: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.name = source["name"];
+	        this.url = source["url"];
+	        this.dest = source["dest"];
+	        this.expected = this.convertValues(source["expected"], Expected);
+	        this.state = source["state"];
+	        this.error = source["error"];
+	        this.downloaded = source["downloaded"];
+	        this.total = source["total"];
+	        this.window = source["window"];
+	        this.added = this.convertValues(source["added"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+
+}
+
 export namespace hyerrors {
 	
+	export class CatalogEntry {
+	    code: string;
+	    category: string;
+	    title: Record<string, string>;
+	    title_key: string;
+	    hint?: Record<string, string>;
+	    hint_key?: string;
+	    action?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CatalogEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.category = source["category"];
+	        this.title = source["title"];
+	        this.title_key = source["title_key"];
+	        this.hint = source["hint"];
+	        this.hint_key = source["hint_key"];
+	        this.action = source["action"];
+	    }
+	}
 	export class Frame {
 	    function: string;
 	    file: string;
@@ -19,13 +153,21 @@ export namespace hyerrors {
 	export class Error {
 	    id: string;
 	    category: string;
+	    code: string;
 	    severity: number;
 	    message: string;
 	    details?: string;
+	    hint?: string;
+	    hint_key?: string;
+	    action?: string;
 	    // Go type: time
 	    timestamp: any;
 	    stack?: Frame[];
 	    context?: Record<string, any>;
+	    trace_id?: string;
+	    span_id?: string;
+	    occurrences?: number;
+	    user_initiated?: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new Error(source);
@@ -35,12 +177,20 @@ export namespace hyerrors {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
 	        this.category = source["category"];
+	        this.code = source["code"];
 	        this.severity = source["severity"];
 	        this.message = source["message"];
 	        this.details = source["details"];
+	        this.hint = source["hint"];
+	        this.hint_key = source["hint_key"];
+	        this.action = source["action"];
 	        this.timestamp = this.convertValues(source["timestamp"], null);
 	        this.stack = this.convertValues(source["stack"], Frame);
 	        this.context = source["context"];
+	        this.trace_id = source["trace_id"];
+	        this.span_id = source["span_id"];
+	        this.occurrences = source["occurrences"];
+	        this.user_initiated = source["user_initiated"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -61,18 +211,111 @@ export namespace hyerrors {
 		    return a;
 		}
 	}
+	
+
+}
+
+export namespace java {
+	
+	export class CleanupResult {
+	    removed: string[];
+	    freed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.removed = source["removed"];
+	        this.freed = source["freed"];
+	    }
+	}
+	export class JREInfo {
+	    version: string;
+	    size: number;
+	    referencedBy: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new JREInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.size = source["size"];
+	        this.referencedBy = source["referencedBy"];
+	    }
+	}
+
+}
+
+export namespace logging {
+	
+	export class Retention {
+	    maxSizeMB: number;
+	    maxFiles: number;
+	    maxAgeDays: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Retention(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxSizeMB = source["maxSizeMB"];
+	        this.maxFiles = source["maxFiles"];
+	        this.maxAgeDays = source["maxAgeDays"];
+	    }
+	}
+
+}
+
+export namespace network {
+	
+	export class ProxyConfig {
+	    mode: string;
+	    http: string;
+	    https: string;
+	    socks5: string;
+	    username: string;
+	    password: string;
+	    noProxy: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxyConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.http = source["http"];
+	        this.https = source["https"];
+	        this.socks5 = source["socks5"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.noProxy = source["noProxy"];
+	    }
+	}
 
 }
 
 export namespace service {
 	
 	export class LogEntry {
+	    id?: string;
 	    // Go type: time
 	    timestamp: any;
 	    severity: number;
 	    category: string;
+	    code?: string;
 	    message: string;
 	    details?: string;
+	    hint?: string;
+	    context?: Record<string, any>;
+	    stack?: hyerrors.Frame[];
+	    trace_id?: string;
+	    occurrences?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new LogEntry(source);
@@ -80,11 +323,18 @@ export namespace service {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
 	        this.timestamp = this.convertValues(source["timestamp"], null);
 	        this.severity = source["severity"];
 	        this.category = source["category"];
+	        this.code = source["code"];
 	        this.message = source["message"];
 	        this.details = source["details"];
+	        this.hint = source["hint"];
+	        this.context = source["context"];
+	        this.stack = this.convertValues(source["stack"], hyerrors.Frame);
+	        this.trace_id = source["trace_id"];
+	        this.occurrences = source["occurrences"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -111,6 +361,16 @@ export namespace service {
 	    num_cpu: number;
 	    go_version: string;
 	    num_goroutine: number;
+	    distro?: string;
+	    kernel?: string;
+	    session?: string;
+	    install_method?: string;
+	    mem_total?: number;
+	    mem_free?: number;
+	    disk_free?: number;
+	    jres?: string[];
+	    butler?: string;
+	    builds?: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new SystemInfo(source);
@@ -123,6 +383,16 @@ export namespace service {
 	        this.num_cpu = source["num_cpu"];
 	        this.go_version = source["go_version"];
 	        this.num_goroutine = source["num_goroutine"];
+	        this.distro = source["distro"];
+	        this.kernel = source["kernel"];
+	        this.session = source["session"];
+	        this.install_method = source["install_method"];
+	        this.mem_total = source["mem_total"];
+	        this.mem_free = source["mem_free"];
+	        this.disk_free = source["disk_free"];
+	        this.jres = source["jres"];
+	        this.butler = source["butler"];
+	        this.builds = source["builds"];
 	    }
 	}
 	export class CrashReport {
@@ -133,6 +403,7 @@ export namespace service {
 	    error?: hyerrors.Error;
 	    system: SystemInfo;
 	    recent_logs?: LogEntry[];
+	    trace?: trace.Event[];
 	
 	    static createFrom(source: any = {}) {
 	        return new CrashReport(source);
@@ -146,6 +417,63 @@ export namespace service {
 	        this.error = this.convertValues(source["error"], hyerrors.Error);
 	        this.system = this.convertValues(source["system"], SystemInfo);
 	        this.recent_logs = this.convertValues(source["recent_logs"], LogEntry);
+	        this.trace = this.convertValues(source["trace"], trace.Event);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DiskUsage {
+	    logs: number;
+	    crashes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiskUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.logs = source["logs"];
+	        this.crashes = source["crashes"];
+	    }
+	}
+
+}
+
+export namespace trace {
+	
+	export class Event {
+	    // Go type: time
+	    time: any;
+	    span_id: string;
+	    kind: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Event(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = this.convertValues(source["time"], null);
+	        this.span_id = source["span_id"];
+	        this.kind = source["kind"];
+	        this.message = source["message"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
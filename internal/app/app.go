@@ -2,20 +2,33 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"HyLauncher/internal/config"
+	"HyLauncher/internal/endpoints"
 	"HyLauncher/internal/env"
 	"HyLauncher/internal/progress"
 	"HyLauncher/internal/service"
+	"HyLauncher/pkg/download"
 	"HyLauncher/pkg/hyerrors"
+	"HyLauncher/pkg/logging"
 	"HyLauncher/pkg/model"
+	"HyLauncher/pkg/network"
+	"HyLauncher/pkg/trace"
 
 	"github.com/hugolgst/rich-go/client"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+var logger = logging.New("app")
+
+// errorQueueSize is how many reported errors may wait for a slow handler
+const errorQueueSize = 64
+
 var AppVersion string = config.LauncherDefault().Version
 
 type App struct {
@@ -27,19 +40,55 @@ type App struct {
 
 	crashSvc *service.Reporter
 	gameSvc  *service.GameService
+	jreSvc   *service.JREService
+
+	// Guards launcherCfg and instance after Startup, bindings, the pre-download
+	// loop and the support bundle use them from their own goroutines
+	cfgMu sync.RWMutex
+
+	downloads    *download.Manager
+	downloadWake chan struct{}
 }
 
 func NewApp() *App {
-	return &App{}
+	return &App{downloadWake: make(chan struct{}, 1)}
+}
+
+// launcherConfig returns a copy of the launcher config
+func (a *App) launcherConfig() config.LauncherConfig {
+	a.cfgMu.RLock()
+	defer a.cfgMu.RUnlock()
+	return *a.launcherCfg
+}
+
+// updateLauncherConfig changes the in-memory launcher config, the caller
+// saves it to disk first
+func (a *App) updateLauncherConfig(fn func(cfg *config.LauncherConfig)) {
+	a.cfgMu.Lock()
+	defer a.cfgMu.Unlock()
+	fn(a.launcherCfg)
+}
+
+// currentInstance returns a copy of the instance the launcher plays
+func (a *App) currentInstance() model.InstanceModel {
+	a.cfgMu.RLock()
+	defer a.cfgMu.RUnlock()
+	return a.instance
 }
 
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
 	a.progress = progress.New(ctx)
 
-	hyerrors.RegisterHandlerFunc(func(err *hyerrors.Error) {
+	if err := logging.Init(filepath.Join(env.GetDefaultAppDir(), "logs")); err != nil {
+		logger.Error("failed to open log file: %v", err)
+	}
+
+	registerErrorClasses()
+
+	hyerrors.RegisterHandler(hyerrors.Async(hyerrors.HandlerFunc(func(err *hyerrors.Error) {
 		runtime.EventsEmit(ctx, "error", err)
-	})
+	}), errorQueueSize))
 
 	err := client.Login("1465005878276128888")
 	if err != nil {
@@ -51,6 +100,24 @@ func (a *App) Startup(ctx context.Context) {
 		panic(err) // launcher config is critical
 	}
 	a.launcherCfg = launcherCfg
+	logging.SetDebug(launcherCfg.Debug)
+	logging.SetRetention(launcherCfg.Logs)
+	download.SetRateLimit(int64(launcherCfg.Downloads.LimitKB) * 1024)
+
+	download.ConfigureGitHub(download.GitHubOptions{
+		Token:    launcherCfg.Updates.GitHubToken,
+		CacheDir: env.GetCacheDir(),
+	})
+
+	if err := network.SetProxy(launcherCfg.Proxy); err != nil {
+		logger.Warn("ignoring invalid proxy settings: %v", err)
+	}
+
+	urls := endpoints.Resolve(launcherCfg.Endpoints)
+	if urls != endpoints.Default() {
+		logger.Info("Using custom endpoints: %+v", urls)
+	}
+	endpoints.Set(urls)
 
 	instanceName := launcherCfg.Instance
 	instanceCfg, err := config.LoadInstance(instanceName)
@@ -62,6 +129,7 @@ func (a *App) Startup(ctx context.Context) {
 	instance, err := config.LoadInstance(instanceName)
 	if err != nil {
 		hyerrors.WrapConfig(err, "failed to get instance").
+			WithCode(hyerrors.CodeConfigLoadFailed).
 			WithContext("default_instance", "default")
 		config.UpdateInstance(instanceName, func(cfg *config.InstanceConfig) error {
 			cfg.ID = instanceName
@@ -74,43 +142,89 @@ func (a *App) Startup(ctx context.Context) {
 		AppVersion,
 	)
 	if err != nil {
-		fmt.Printf("failed to initialize diagnostics: %v\n", err)
+		logger.Error("failed to initialize diagnostics: %v", err)
 	}
 
 	a.instance.Branch = a.instanceCfg.Branch
 	a.instance.BuildVersion = instance.Build
 	a.instance.InstanceID = instance.ID
 	a.instance.InstanceName = instance.Name
+	a.instance.JREVersion = instance.JRE
+
+	a.downloads = download.NewManager(filepath.Join(env.GetDefaultAppDir(), "downloads.json"))
+	a.downloads.OnChange(func(jobs []download.Job) {
+		runtime.EventsEmit(ctx, "downloads-update", jobs)
+	})
+	download.SetDefaultManager(a.downloads)
 
 	a.crashSvc = crashReporter
 	a.gameSvc = service.NewGameService(ctx, a.progress)
+	a.jreSvc = service.NewJREService()
 
-	fmt.Printf("Application starting: v%s, branch=%s\n", AppVersion, a.instance.Branch)
+	logger.Info("Application starting: v%s, branch=%s", AppVersion, a.instance.Branch)
 
 	go a.discordRPC()
 	go env.CreateFolders(a.instance.InstanceID)
 	go a.checkUpdateSilently()
 	go env.CleanupLauncher(a.instance)
+	go a.downloads.Run(ctx)
+	go a.preDownloadLoop()
 }
 
-func (a *App) DownloadAndLaunch(playerName string) error {
+func (a *App) DownloadAndLaunch(playerName string) (err error) {
 	if err := a.validatePlayerName(playerName); err != nil {
 		hyerrors.Report(hyerrors.Validation("provided invalid username"))
 		return err
 	}
 
-	if err := a.gameSvc.EnsureInstalled(a.ctx, a.instance, a.progress); err != nil {
+	// Logs, progress and errors of this run share one trace ID
+	ctx, span := trace.Start(a.ctx, "download_and_launch")
+	defer func() { span.End(err) }()
+
+	instance := a.currentInstance()
+	logger.Ctx(ctx).Info("Download and launch %s for %s", instance.Branch, playerName)
+
+	reporter := a.progress.WithContext(ctx)
+
+	build, err := a.gameSvc.EnsureInstalled(ctx, instance, reporter)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			reporter.Reset()
+			return err
+		}
+
+		// Wrap reports Java, network and disk errors as what they are
 		appErr := hyerrors.WrapGame(err, "failed to install game").
-			WithContext("branch", a.instance.Branch)
+			WithContext("branch", instance.Branch).
+			WithTrace(ctx).
+			ByUser()
+		switch appErr.Code {
+		case hyerrors.CodeGameError:
+			appErr = appErr.WithCode(hyerrors.CodeGameInstallFailed)
+		case hyerrors.CodeNetError:
+			// Installing only talks to the patch and JRE servers
+			appErr = appErr.WithCode(hyerrors.CodeNetPatchUnreachable)
+		}
 		hyerrors.Report(appErr)
 		return appErr
 	}
 
-	if err := a.gameSvc.Launch(playerName, a.instance); err != nil {
-		appErr := hyerrors.GameCritical("failed to launch game").
-			WithDetails(err.Error()).
+	// An install brings the instance to the newest build
+	instance.BuildVersion = build
+	a.cfgMu.Lock()
+	a.instance.BuildVersion = build
+	a.cfgMu.Unlock()
+
+	if err := a.gameSvc.Launch(ctx, playerName, instance); err != nil {
+		appErr := hyerrors.WrapGame(err, "failed to launch game").
 			WithContext("player", playerName).
-			WithContext("branch", a.instance.Branch)
+			WithContext("branch", instance.Branch).
+			WithTrace(ctx).
+			ByUser()
+		if appErr.Code == hyerrors.CodeGameError {
+			appErr = appErr.WithCode(hyerrors.CodeGameLaunchFailed).
+				WithSeverity(hyerrors.SeverityCritical)
+		}
 		hyerrors.Report(appErr)
 		return appErr
 	}
@@ -118,6 +232,34 @@ func (a *App) DownloadAndLaunch(playerName string) error {
 	return nil
 }
 
+// VerifyGameFiles runs a full CRC check of the installed game assets
+func (a *App) VerifyGameFiles() error {
+	instance := a.currentInstance()
+	if err := a.gameSvc.VerifyAssets(a.ctx, instance); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+
+		appErr := hyerrors.WrapGame(err, "failed to verify game files").
+			WithContext("branch", instance.Branch).
+			WithContext("build", instance.BuildVersion).
+			ByUser()
+		// Only a failed check means damaged files, not a disk or permission error
+		if appErr.Code == hyerrors.CodeGameError {
+			appErr = appErr.WithCode(hyerrors.CodeGameCorrupted)
+			appErr.Message = "game files are corrupted, reinstall the game"
+		}
+		hyerrors.Report(appErr)
+		return appErr
+	}
+	return nil
+}
+
+// CancelInstall aborts a running game installation
+func (a *App) CancelInstall() {
+	a.gameSvc.CancelInstall()
+}
+
 func (a *App) validatePlayerName(name string) error {
 	if len(name) == 0 {
 		return hyerrors.Validation("please enter a nickname")
@@ -129,13 +271,6 @@ func (a *App) validatePlayerName(name string) error {
 	return nil
 }
 
-func (a *App) GetLogs() (string, error) {
-	if a.crashSvc == nil {
-		return "", fmt.Errorf("diagnostics not initialized")
-	}
-	return a.crashSvc.GetLogs()
-}
-
 func (a *App) GetCrashReports() ([]service.CrashReport, error) {
 	if a.crashSvc == nil {
 		return nil, fmt.Errorf("diagnostics not initialized")
@@ -165,6 +300,6 @@ func (a *App) discordRPC() {
 	})
 
 	if err != nil {
-		fmt.Println("Error occured, %w", err)
+		logger.Warn("Discord rich presence failed: %v", err)
 	}
 }
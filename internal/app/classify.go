@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"HyLauncher/internal/java"
+	"HyLauncher/internal/patch"
+	"HyLauncher/pkg/download"
+	"HyLauncher/pkg/hyerrors"
+	"HyLauncher/pkg/network"
+)
+
+// registerErrorClasses teaches hyerrors.Wrap the sentinels and typed errors
+// of the launcher packages, so a missing JRE deep inside an install is
+// reported as a Java problem rather than a game one
+func registerErrorClasses() {
+	hyerrors.RegisterSentinel(java.ErrJavaNotFound, hyerrors.Class{
+		Category: hyerrors.CategoryJava, Severity: hyerrors.SeverityError, Code: hyerrors.CodeJavaNotFound,
+	})
+	hyerrors.RegisterSentinel(java.ErrJavaBroken, hyerrors.Class{
+		Category: hyerrors.CategoryJava, Severity: hyerrors.SeverityError, Code: hyerrors.CodeJavaBroken,
+	})
+	hyerrors.RegisterSentinel(patch.ErrButlerNotFound, hyerrors.Class{
+		Category: hyerrors.CategoryGame, Severity: hyerrors.SeverityError, Code: hyerrors.CodeButlerNotFound,
+	})
+	hyerrors.RegisterSentinel(patch.ErrButlerBroken, hyerrors.Class{
+		Category: hyerrors.CategoryGame, Severity: hyerrors.SeverityError, Code: hyerrors.CodeButlerBroken,
+	})
+	hyerrors.RegisterClassifier(classifyNetwork)
+}
+
+func classifyNetwork(err error) (hyerrors.Class, bool) {
+	class := hyerrors.Class{Category: hyerrors.CategoryNetwork, Severity: hyerrors.SeverityError}
+
+	var rateErr *download.RateLimitError
+	if errors.As(err, &rateErr) {
+		class.Severity = hyerrors.SeverityWarning
+		class.Code = hyerrors.CodeNetRateLimited
+		return class, true
+	}
+
+	var netErr *network.Error
+	if errors.As(err, &netErr) {
+		switch netErr.Kind {
+		case network.KindDNS:
+			class.Code = hyerrors.CodeNetDNS
+		case network.KindTLS:
+			class.Code = hyerrors.CodeNetTLS
+		case network.KindTimeout:
+			class.Code = hyerrors.CodeNetTimeout
+		case network.KindProxy:
+			class.Code = hyerrors.CodeNetProxyFailed
+		default:
+			class.Code = hyerrors.CodeNetError
+		}
+		return class, true
+	}
+
+	var statusErr *network.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		class.Code = hyerrors.CodeNetError
+		if statusErr.StatusCode == http.StatusProxyAuthRequired {
+			class.Code = hyerrors.CodeNetProxyFailed
+		}
+		return class, true
+	}
+
+	return hyerrors.Class{}, false
+}
@@ -19,24 +19,30 @@ func (a *App) SetNick(nick, instanceID string) error {
 
 	if err != nil {
 		appErr := hyerrors.WrapConfig(err, "failed to save nickname").
+			WithCode(hyerrors.CodeConfigSaveFailed).
 			WithContext("nick", nick)
 		hyerrors.Report(appErr)
 		return appErr
 	}
 
-	a.launcherCfg.Nick = nick
+	a.updateLauncherConfig(func(cfg *config.LauncherConfig) {
+		cfg.Nick = nick
+	})
 	return nil
 }
 
 func (a *App) GetNick() (string, error) {
 	cfg, err := config.LoadLauncher()
 	if err != nil {
-		appErr := hyerrors.WrapConfig(err, "failed to get nickname")
+		appErr := hyerrors.WrapConfig(err, "failed to get nickname").
+			WithCode(hyerrors.CodeConfigLoadFailed)
 		hyerrors.Report(appErr)
 		return "", appErr
 	}
 
-	a.launcherCfg.Nick = cfg.Nick
+	a.updateLauncherConfig(func(launcherCfg *config.LauncherConfig) {
+		launcherCfg.Nick = cfg.Nick
+	})
 	return cfg.Nick, nil
 }
 
@@ -58,6 +64,7 @@ func (a *App) SetLocalGameVersion(version int, instanceID string) error {
 
 	if err != nil {
 		appErr := hyerrors.WrapConfig(err, "failed to save game version").
+			WithCode(hyerrors.CodeConfigSaveFailed).
 			WithContext("version", version).
 			WithContext("instance", instanceID)
 		hyerrors.Report(appErr)
@@ -72,6 +79,7 @@ func (a *App) GetLocalGameVersion(instanceID string) (int, error) {
 	cfg, err := config.LoadInstance(instanceID)
 	if err != nil {
 		appErr := hyerrors.WrapConfig(err, "failed to get game version").
+			WithCode(hyerrors.CodeConfigLoadFailed).
 			WithContext("instance", instanceID)
 		hyerrors.Report(appErr)
 		return 0, appErr
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"HyLauncher/internal/config"
+	"HyLauncher/pkg/download"
+	"HyLauncher/pkg/hyerrors"
+)
+
+// preDownloadInterval is how often the background loop looks for new builds
+const preDownloadInterval = time.Hour
+
+// GetDownloadSettings returns the bandwidth limit and background download settings
+func (a *App) GetDownloadSettings() config.DownloadConfig {
+	return a.launcherConfig().Downloads
+}
+
+// SetDownloadSettings saves the settings and applies them to running downloads
+func (a *App) SetDownloadSettings(settings config.DownloadConfig) error {
+	if settings.LimitKB < 0 {
+		err := hyerrors.Validation("download limit cannot be negative")
+		hyerrors.Report(err)
+		return err
+	}
+
+	if _, err := download.ParseWindow(settings.Window); err != nil {
+		appErr := hyerrors.Validation("invalid download window, use HH:MM-HH:MM").
+			WithDetails(err.Error()).
+			WithContext("window", settings.Window)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	err := config.UpdateLauncher(func(cfg *config.LauncherConfig) error {
+		cfg.Downloads = settings
+		return nil
+	})
+	if err != nil {
+		appErr := hyerrors.WrapConfig(err, "failed to save download settings").
+			WithCode(hyerrors.CodeConfigSaveFailed)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	a.updateLauncherConfig(func(cfg *config.LauncherConfig) {
+		cfg.Downloads = settings
+	})
+
+	download.SetRateLimit(int64(settings.LimitKB) * 1024)
+
+	// Let the background loop see a new window or switch right away
+	select {
+	case a.downloadWake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// SetDownloadLimit changes only the bandwidth limit in KiB/s, 0 is unlimited
+func (a *App) SetDownloadLimit(limitKB int) error {
+	settings := a.GetDownloadSettings()
+	settings.LimitKB = limitKB
+	return a.SetDownloadSettings(settings)
+}
+
+// preDownloadLoop fetches new builds in the background while pre-downloads are
+// enabled and the download window is open
+func (a *App) preDownloadLoop() {
+	for {
+		wait := preDownloadInterval
+
+		settings := a.GetDownloadSettings()
+		if settings.PreDownload {
+			window, err := download.ParseWindow(settings.Window)
+			if err != nil {
+				logger.Warn("ignoring invalid download window: %v", err)
+			} else if untilOpen := window.UntilOpen(time.Now()); untilOpen > 0 {
+				wait = min(wait, untilOpen)
+			} else {
+				a.preDownload(settings.Window)
+			}
+		}
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-a.downloadWake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (a *App) preDownload(window string) {
+	// The queue keeps the job inside the window, also after a restart
+	ctx := download.WithWindow(a.ctx, window)
+
+	if err := a.gameSvc.PreDownload(ctx, a.currentInstance()); err != nil && !errors.Is(err, context.Canceled) {
+		logger.Warn("pre-download failed: %v", err)
+	}
+}
+
+// GetDownloads returns the download queue in order
+func (a *App) GetDownloads() []download.Job {
+	return a.downloads.Jobs()
+}
+
+// PauseDownload stops a download, keeping what was already downloaded
+func (a *App) PauseDownload(id string) error {
+	return a.reportJobErr(a.downloads.Pause(id), "failed to pause download", id)
+}
+
+// ResumeDownload queues a paused or failed download again
+func (a *App) ResumeDownload(id string) error {
+	return a.reportJobErr(a.downloads.Resume(id), "failed to resume download", id)
+}
+
+// CancelDownload removes a download from the queue and deletes its partial data
+func (a *App) CancelDownload(id string) error {
+	return a.reportJobErr(a.downloads.Cancel(id), "failed to cancel download", id)
+}
+
+// MoveDownload puts a download at index in the queue
+func (a *App) MoveDownload(id string, index int) error {
+	return a.reportJobErr(a.downloads.Move(id, index), "failed to reorder downloads", id)
+}
+
+func (a *App) reportJobErr(err error, message, id string) error {
+	if err == nil {
+		return nil
+	}
+
+	appErr := hyerrors.Wrap(err, hyerrors.CategoryValidation, message).
+		WithContext("id", id)
+	hyerrors.Report(appErr)
+	return appErr
+}
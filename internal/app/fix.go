@@ -0,0 +1,59 @@
+package app
+
+import (
+	"HyLauncher/internal/java"
+	"HyLauncher/internal/patch"
+	"HyLauncher/pkg/hyerrors"
+)
+
+// GetErrorCatalog returns the title, hint and fix action of every error code
+func (a *App) GetErrorCatalog() []hyerrors.CatalogEntry {
+	return hyerrors.Catalog()
+}
+
+// RunFixAction runs the fix the UI offered next to an error. Actions like
+// retry and open_settings are handled by the UI itself.
+func (a *App) RunFixAction(action hyerrors.Action) error {
+	switch action {
+	case hyerrors.ActionVerifyGame:
+		return a.VerifyGameFiles()
+
+	case hyerrors.ActionRepairJava:
+		branch := a.currentInstance().Branch
+		if err := java.EnsureJRE(a.ctx, branch, a.progress); err != nil {
+			// Network and disk errors keep their own code and fix
+			appErr := hyerrors.WrapJava(err, "failed to reinstall Java").
+				WithContext("branch", branch).
+				ByUser()
+			if appErr.Code == hyerrors.CodeJavaError {
+				appErr = appErr.WithCode(hyerrors.CodeJavaBroken)
+			}
+			hyerrors.Report(appErr)
+			return appErr
+		}
+		return nil
+
+	case hyerrors.ActionRepairButler:
+		if err := patch.EnsureButler(a.ctx, a.progress); err != nil {
+			appErr := hyerrors.WrapGame(err, "failed to reinstall butler").ByUser()
+			if appErr.Code == hyerrors.CodeGameError {
+				appErr = appErr.WithCode(hyerrors.CodeButlerBroken)
+			}
+			hyerrors.Report(appErr)
+			return appErr
+		}
+		return nil
+
+	case hyerrors.ActionCleanupJREs:
+		_, err := a.CleanupUnusedJREs()
+		return err
+
+	case hyerrors.ActionOpenAppFolder:
+		return a.OpenFolder()
+	}
+
+	err := hyerrors.Validation("this fix cannot run in the launcher backend").
+		WithContext("action", action)
+	hyerrors.Report(err)
+	return err
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"strings"
+
+	"HyLauncher/internal/config"
+	"HyLauncher/internal/java"
+	"HyLauncher/pkg/hyerrors"
+)
+
+// GetInstanceJRE returns the JRE version the instance is pinned to, "" when
+// it follows the branch manifest
+func (a *App) GetInstanceJRE() string {
+	return a.currentInstance().JREVersion
+}
+
+// SetInstanceJRE pins the instance to an installed JRE version. "" clears the
+// pin, so the branch manifest decides again.
+func (a *App) SetInstanceJRE(version string) error {
+	version = strings.TrimSpace(version)
+	if version != "" {
+		if err := java.VerifyInstalled(version); err != nil {
+			appErr := hyerrors.Validation("JRE version is not installed").
+				WithDetails(err.Error()).
+				WithContext("version", version)
+			hyerrors.Report(appErr)
+			return appErr
+		}
+	}
+
+	instanceID := a.launcherConfig().Instance
+	err := config.UpdateInstance(instanceID, func(cfg *config.InstanceConfig) error {
+		cfg.JRE = version
+		return nil
+	})
+	if err != nil {
+		appErr := hyerrors.WrapConfig(err, "failed to save instance JRE").
+			WithCode(hyerrors.CodeConfigSaveFailed).
+			WithContext("version", version).
+			WithContext("instance", instanceID)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	a.cfgMu.Lock()
+	a.instance.JREVersion = version
+	a.cfgMu.Unlock()
+	return nil
+}
+
+// GetUnusedJREs lists installed JREs that can be removed, with their sizes
+func (a *App) GetUnusedJREs() ([]java.JREInfo, error) {
+	jres, err := a.jreSvc.ListUnused()
+	if err != nil {
+		appErr := hyerrors.WrapJava(err, "failed to list unused JREs")
+		hyerrors.Report(appErr)
+		return nil, appErr
+	}
+	return jres, nil
+}
+
+// CleanupUnusedJREs removes installed JREs no build or instance references
+func (a *App) CleanupUnusedJREs() (*java.CleanupResult, error) {
+	result, err := a.jreSvc.CleanupUnused()
+	if err != nil {
+		appErr := hyerrors.WrapJava(err, "failed to remove unused JREs")
+		if result != nil {
+			appErr = appErr.WithContext("removed", result.Removed)
+		}
+		hyerrors.Report(appErr)
+		return nil, appErr
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"HyLauncher/internal/config"
+	"HyLauncher/internal/service"
+	"HyLauncher/pkg/hyerrors"
+	"HyLauncher/pkg/logging"
+)
+
+// GetLogs returns the launcher log, which also holds every reported error.
+// Before the log file is open it falls back to errors.log.
+func (a *App) GetLogs() (string, error) {
+	path := logging.Path()
+	if path == "" {
+		if a.crashSvc == nil {
+			return "", fmt.Errorf("diagnostics not initialized")
+		}
+		return a.crashSvc.GetLogs()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		appErr := hyerrors.WrapFileSystem(err, "failed to read launcher log").
+			WithContext("path", path)
+		hyerrors.Report(appErr)
+		return "", appErr
+	}
+	return string(data), nil
+}
+
+// GetDebugLogging reports whether debug messages are written to the log
+func (a *App) GetDebugLogging() bool {
+	return a.launcherConfig().Debug
+}
+
+// SetDebugLogging saves the debug toggle and applies it right away
+func (a *App) SetDebugLogging(enabled bool) error {
+	err := config.UpdateLauncher(func(cfg *config.LauncherConfig) error {
+		cfg.Debug = enabled
+		return nil
+	})
+	if err != nil {
+		appErr := hyerrors.WrapConfig(err, "failed to save debug logging").
+			WithCode(hyerrors.CodeConfigSaveFailed)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	a.updateLauncherConfig(func(cfg *config.LauncherConfig) {
+		cfg.Debug = enabled
+	})
+	logging.SetDebug(enabled)
+	logger.Info("Debug logging set to %v", enabled)
+	return nil
+}
+
+// GetLogSettings returns when logs are rotated and how long they are kept
+func (a *App) GetLogSettings() logging.Retention {
+	return a.launcherConfig().Logs
+}
+
+// SetLogSettings saves the log retention, the next rotation and the daily
+// cleanup use it
+func (a *App) SetLogSettings(settings logging.Retention) error {
+	if err := settings.Validate(); err != nil {
+		appErr := hyerrors.Validation("invalid log settings").
+			WithDetails(err.Error())
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	err := config.UpdateLauncher(func(cfg *config.LauncherConfig) error {
+		cfg.Logs = settings
+		return nil
+	})
+	if err != nil {
+		appErr := hyerrors.WrapConfig(err, "failed to save log settings").
+			WithCode(hyerrors.CodeConfigSaveFailed)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	a.updateLauncherConfig(func(cfg *config.LauncherConfig) {
+		cfg.Logs = settings
+	})
+	logging.SetRetention(settings)
+	return nil
+}
+
+// GetLogDiskUsage returns how much space logs and crash reports take
+func (a *App) GetLogDiskUsage() (service.DiskUsage, error) {
+	if a.crashSvc == nil {
+		return service.DiskUsage{}, fmt.Errorf("diagnostics not initialized")
+	}
+
+	usage, err := a.crashSvc.DiskUsage()
+	if err != nil {
+		appErr := hyerrors.WrapFileSystem(err, "failed to measure log size")
+		hyerrors.Report(appErr)
+		return service.DiskUsage{}, appErr
+	}
+	return usage, nil
+}
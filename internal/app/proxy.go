@@ -0,0 +1,68 @@
+package app
+
+import (
+	"HyLauncher/internal/config"
+	"HyLauncher/internal/endpoints"
+	"HyLauncher/pkg/hyerrors"
+	"HyLauncher/pkg/network"
+)
+
+// GetProxySettings returns the proxy all launcher requests go through
+func (a *App) GetProxySettings() network.ProxyConfig {
+	return a.launcherConfig().Proxy
+}
+
+// SetProxySettings saves the proxy and applies it to every client right away
+func (a *App) SetProxySettings(settings network.ProxyConfig) error {
+	if err := settings.Validate(); err != nil {
+		appErr := hyerrors.Validation("invalid proxy settings").
+			WithDetails(err.Error()).
+			WithContext("mode", settings.Mode)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	err := config.UpdateLauncher(func(cfg *config.LauncherConfig) error {
+		cfg.Proxy = settings
+		return nil
+	})
+	if err != nil {
+		appErr := hyerrors.WrapConfig(err, "failed to save proxy settings").
+			WithCode(hyerrors.CodeConfigSaveFailed)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	a.updateLauncherConfig(func(cfg *config.LauncherConfig) {
+		cfg.Proxy = settings
+	})
+	return network.SetProxy(settings)
+}
+
+// TestProxy checks that the game servers and GitHub can be reached through
+// settings, without saving them
+func (a *App) TestProxy(settings network.ProxyConfig) error {
+	client, err := network.NewProxyClient(settings)
+	if err != nil {
+		appErr := hyerrors.Validation("invalid proxy settings").
+			WithDetails(err.Error()).
+			WithContext("mode", settings.Mode)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	urls := endpoints.Get()
+	for _, target := range []string{urls.Patches, urls.GitHubAPI} {
+		if err := network.TestConnectionWith(client, target); err != nil {
+			appErr := hyerrors.WrapNetwork(err, "proxy test failed").
+				WithCode(hyerrors.CodeNetProxyFailed).
+				WithContext("mode", settings.Mode).
+				WithContext("target", target).
+				ByUser()
+			hyerrors.Report(appErr)
+			return appErr
+		}
+	}
+
+	return nil
+}
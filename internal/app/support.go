@@ -0,0 +1,34 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"HyLauncher/internal/env"
+	"HyLauncher/pkg/hyerrors"
+)
+
+// ExportSupportBundle packs logs, crash reports, redacted configs and system
+// info into one zip under support/ and returns its path, for attaching to a
+// bug report
+func (a *App) ExportSupportBundle() (string, error) {
+	if a.crashSvc == nil {
+		return "", fmt.Errorf("diagnostics not initialized")
+	}
+
+	name := fmt.Sprintf("hylauncher-support-%s.zip", time.Now().Format("20060102-150405"))
+	dest := filepath.Join(env.GetDefaultAppDir(), "support", name)
+
+	// A copy, the bundle is written while settings may still change
+	if err := a.crashSvc.ExportSupportBundle(a.ctx, dest, a.launcherConfig()); err != nil {
+		appErr := hyerrors.WrapFileSystem(err, "failed to export support bundle").
+			WithContext("path", dest).
+			ByUser()
+		hyerrors.Report(appErr)
+		return "", appErr
+	}
+
+	logger.Info("Support bundle written to %s", dest)
+	return dest, nil
+}
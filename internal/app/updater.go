@@ -1,14 +1,17 @@
 package app
 
 import (
+	"HyLauncher/internal/config"
+	"HyLauncher/internal/env"
 	"HyLauncher/internal/platform"
 	"HyLauncher/internal/progress"
 	"HyLauncher/internal/updater"
-	"HyLauncher/pkg/fileutil"
+	"HyLauncher/pkg/download"
 	"HyLauncher/pkg/hyerrors"
-	"fmt"
+	"errors"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 
 	"runtime"
@@ -17,82 +20,83 @@ import (
 )
 
 func (a *App) CheckUpdate() (*updater.Asset, error) {
-	fmt.Println("Checking for launcher updates...")
+	logger.Info("Checking for launcher updates...")
 
-	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion)
+	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion, a.updateChannel())
 	if err != nil {
-		fmt.Printf("Update check failed: %v\n", err)
+		logger.Warn("Update check failed: %v", err)
 		// Don't report - this is expected when offline
 		return nil, nil
 	}
 
 	if asset != nil {
-		fmt.Printf("Update available: %s\n", newVersion)
+		logger.Info("Update available: %s", newVersion)
 	} else {
-		fmt.Println("No update available")
+		logger.Info("No update available")
 	}
 
 	return asset, nil
 }
 
 func (a *App) Update() error {
-	fmt.Println("Starting launcher update process...")
+	logger.Info("Starting launcher update process...")
 
-	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion)
+	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion, a.updateChannel())
 	if err != nil {
 		appErr := hyerrors.WrapNetwork(err, "failed to check for updates").
-			WithContext("current_version", AppVersion)
+			WithCode(hyerrors.CodeUpdateCheckFailed).
+			WithContext("current_version", AppVersion).
+			ByUser()
 		hyerrors.Report(appErr)
 		return appErr
 	}
 
 	if asset == nil {
-		fmt.Println("No update available")
+		logger.Info("No update available")
 		return nil
 	}
 
-	fmt.Printf("Downloading update from: %s\n", asset.URL)
+	logger.Info("Downloading update from: %s", asset.URL)
 
 	reporter := progress.New(a.ctx)
 
-	tmp, err := updater.DownloadTemp(a.ctx, asset.URL, reporter)
+	if asset.Sha256 == "" {
+		logger.Warn("No checksum provided, skipping verification")
+	}
+
+	tmp, err := updater.DownloadTemp(a.ctx, asset.URL, asset.Sha256, reporter)
 	if err != nil {
-		appErr := hyerrors.WrapNetwork(err, "failed to download update").
+		var appErr *hyerrors.Error
+		if errors.Is(err, download.ErrChecksumMismatch) {
+			appErr = hyerrors.WrapFileSystem(err, "update file verification failed").
+				WithCode(hyerrors.CodeUpdateVerifyFailed).
+				WithContext("expected_sha256", asset.Sha256)
+		} else {
+			appErr = hyerrors.WrapNetwork(err, "failed to download update").
+				WithCode(hyerrors.CodeUpdateDownloadFailed)
+		}
+		appErr = appErr.
 			WithContext("url", asset.URL).
-			WithContext("version", newVersion)
+			WithContext("version", newVersion).
+			ByUser()
 		hyerrors.Report(appErr)
 		return appErr
 	}
 
-	if asset.Sha256 != "" {
-		fmt.Println("Verifying download checksum...")
-		reporter.Report(progress.StageUpdate, 100, "Verifying checksum...")
-
-		if err := fileutil.VerifySHA256(tmp, asset.Sha256); err != nil {
-			os.Remove(tmp)
-			appErr := hyerrors.WrapFileSystem(err, "update file verification failed").
-				WithContext("expected_sha256", asset.Sha256).
-				WithContext("file", tmp)
-			hyerrors.Report(appErr)
-			return appErr
-		}
-		fmt.Println("Checksum verified successfully")
-	} else {
-		fmt.Println("Warning: No checksum provided, skipping verification")
-	}
-
-	fmt.Println("Preparing update helper...")
-	helperPath, err := updater.EnsureUpdateHelper(a.ctx)
+	logger.Info("Preparing update helper...")
+	helperPath, err := updater.EnsureUpdateHelper(a.ctx, a.updateChannel())
 	if err != nil {
-		appErr := hyerrors.WrapFileSystem(err, "failed to prepare update helper")
+		appErr := hyerrors.WrapFileSystem(err, "failed to prepare update helper").
+			WithCode(hyerrors.CodeUpdateHelperFailed).
+			ByUser()
 		hyerrors.Report(appErr)
 		return appErr
 	}
 
-	fmt.Printf("Running update helper: %s\n", helperPath)
+	logger.Info("Running update helper: %s", helperPath)
 	exe, err := os.Executable()
 	if err != nil {
-		appErr := hyerrors.WrapFileSystem(err, "failed to get executable path")
+		appErr := hyerrors.WrapFileSystem(err, "failed to get executable path").ByUser()
 		hyerrors.Report(appErr)
 		return appErr
 	}
@@ -106,18 +110,20 @@ func (a *App) Update() error {
 
 	if err := cmd.Start(); err != nil {
 		appErr := hyerrors.WrapUpdate(err, "failed to start update helper").
+			WithCode(hyerrors.CodeUpdateHelperFailed).
 			WithContext("helper_path", helperPath).
 			WithContext("launcher_path", exe).
-			WithContext("update_file", tmp)
+			WithContext("update_file", tmp).
+			ByUser()
 		hyerrors.Report(appErr)
 		return appErr
 	}
 
 	if err := cmd.Process.Release(); err != nil {
-		fmt.Printf("Warning: failed to release helper process: %v\n", err)
+		logger.Warn("failed to release helper process: %v", err)
 	}
 
-	fmt.Printf("Update helper started successfully, exiting launcher (updating to version %s)...\n", newVersion)
+	logger.Info("Update helper started successfully, exiting launcher (updating to version %s)...", newVersion)
 
 	time.Sleep(500 * time.Millisecond)
 	os.Exit(0)
@@ -128,19 +134,68 @@ func (a *App) checkUpdateSilently() {
 	if runtime.GOOS != "windows" {
 		return
 	}
-	fmt.Println("Running silent update check...")
+	logger.Info("Running silent update check...")
 
-	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion)
+	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion, a.updateChannel())
 	if err != nil {
-		fmt.Printf("Silent update check failed (this is normal if offline): %v\n", err)
+		logger.Info("Silent update check failed (this is normal if offline): %v", err)
 		return
 	}
 
 	if asset == nil {
-		fmt.Println("No update available (silent check)")
+		logger.Info("No update available (silent check)")
 		return
 	}
 
-	fmt.Printf("Update available: %s (notifying frontend)\n", newVersion)
+	logger.Info("Update available: %s (notifying frontend)", newVersion)
 	wailsRuntime.EventsEmit(a.ctx, "update:available", asset)
 }
+
+// updateChannel is the configured channel, stable when the config is invalid
+func (a *App) updateChannel() download.Channel {
+	channel, err := download.ParseChannel(a.launcherConfig().Updates.Channel)
+	if err != nil {
+		logger.Warn("%v", err)
+		return download.ChannelStable
+	}
+	return channel
+}
+
+// GetUpdateSettings returns the update channel and GitHub token
+func (a *App) GetUpdateSettings() config.UpdateConfig {
+	return a.launcherConfig().Updates
+}
+
+// SetUpdateSettings saves the update channel and token, the next check uses them
+func (a *App) SetUpdateSettings(settings config.UpdateConfig) error {
+	channel, err := download.ParseChannel(settings.Channel)
+	if err != nil {
+		appErr := hyerrors.Validation("invalid update channel").
+			WithDetails(err.Error()).
+			WithContext("channel", settings.Channel)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+	settings.Channel = string(channel)
+	settings.GitHubToken = strings.TrimSpace(settings.GitHubToken)
+
+	err = config.UpdateLauncher(func(cfg *config.LauncherConfig) error {
+		cfg.Updates = settings
+		return nil
+	})
+	if err != nil {
+		appErr := hyerrors.WrapConfig(err, "failed to save update settings").
+			WithCode(hyerrors.CodeConfigSaveFailed)
+		hyerrors.Report(appErr)
+		return appErr
+	}
+
+	a.updateLauncherConfig(func(cfg *config.LauncherConfig) {
+		cfg.Updates = settings
+	})
+	download.ConfigureGitHub(download.GitHubOptions{
+		Token:    settings.GitHubToken,
+		CacheDir: env.GetCacheDir(),
+	})
+	return nil
+}
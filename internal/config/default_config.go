@@ -1,9 +1,17 @@
 package config
 
+import (
+	"HyLauncher/pkg/logging"
+	"HyLauncher/pkg/network"
+)
+
 var launcherDefaults = LauncherConfig{
 	Nick:     "HyLauncher",
 	Version:  "0.6.6",
 	Instance: "default",
+	Proxy:    network.ProxyConfig{Mode: network.ProxySystem},
+	Updates:  UpdateConfig{Channel: "stable"},
+	Logs:     logging.DefaultRetention,
 }
 
 var instanceDefaults = InstanceConfig{
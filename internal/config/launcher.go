@@ -29,3 +29,13 @@ func UpdateLauncher(update func(*LauncherConfig) error) error {
 
 	return SaveLauncher(cfg)
 }
+
+// Redacted returns cfg without the GitHub token and proxy credentials, for
+// sharing in support bundles
+func (cfg LauncherConfig) Redacted() LauncherConfig {
+	if cfg.Updates.GitHubToken != "" {
+		cfg.Updates.GitHubToken = "[redacted]"
+	}
+	cfg.Proxy = cfg.Proxy.Redacted()
+	return cfg
+}
@@ -1,14 +1,38 @@
 package config
 
+import (
+	"HyLauncher/internal/endpoints"
+	"HyLauncher/pkg/logging"
+	"HyLauncher/pkg/network"
+)
+
 type LauncherConfig struct {
-	Nick     string `toml:"nick"`
-	Version  string `toml:"version"`
-	Instance string `toml:"instance"`
+	Nick      string              `toml:"nick"`
+	Version   string              `toml:"version"`
+	Instance  string              `toml:"instance"`
+	Downloads DownloadConfig      `toml:"downloads"`
+	Endpoints endpoints.Endpoints `toml:"endpoints"` // Overrides for mirrors, HYLAUNCHER_*_URL variables win over these
+	Proxy     network.ProxyConfig `toml:"proxy"`
+	Updates   UpdateConfig        `toml:"updates"`
+	Logs      logging.Retention   `toml:"logs"`
+	Debug     bool                `toml:"debug"` // Write debug messages to logs/launcher.log
+}
+
+type UpdateConfig struct {
+	Channel     string `toml:"channel" json:"channel"`          // "stable" or "prerelease"
+	GitHubToken string `toml:"github_token" json:"githubToken"` // Optional, lifts the GitHub API rate limit
+}
+
+type DownloadConfig struct {
+	LimitKB     int    `toml:"limit_kb" json:"limitKB"`         // KiB/s shared by all downloads, 0 is unlimited
+	Window      string `toml:"window" json:"window"`            // "HH:MM-HH:MM" for background pre-downloads, empty is any time
+	PreDownload bool   `toml:"pre_download" json:"preDownload"` // Fetch new builds in the background
 }
 
 type InstanceConfig struct {
 	ID     string `toml:"id"`
 	Name   string `toml:"name"` // Instance name
 	Branch string `toml:"branch"`
-	Build  int    `toml:"build"` // Game build aka version
+	Build  int    `toml:"build"`         // Game build aka version
+	JRE    string `toml:"jre,omitempty"` // Pinned JRE version, empty uses the branch manifest
 }
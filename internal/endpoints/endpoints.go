@@ -0,0 +1,118 @@
+package endpoints
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Endpoints are the servers the launcher talks to. Empty fields keep the
+// value they override, so a config only lists the hosts it changes.
+type Endpoints struct {
+	Patches    string `toml:"patches,omitempty"`     // Game patches, <base>/<os>/<arch>/<branch>/0/<build>.pwr
+	JRE        string `toml:"jre,omitempty"`         // JRE manifests, <base>/<branch>/jre.json
+	Butler     string `toml:"butler,omitempty"`      // butler builds, <base>/<os>-<arch>/LATEST/archive/default
+	GitHubAPI  string `toml:"github_api,omitempty"`  // GitHub REST API
+	GitHubRepo string `toml:"github_repo,omitempty"` // owner/name of the launcher releases
+	OnlineFix  string `toml:"online_fix,omitempty"`  // Online fix metadata JSON
+}
+
+var defaults = Endpoints{
+	Patches:    "https://game-patches.hytale.com/patches",
+	JRE:        "https://launcher.hytale.com/version",
+	Butler:     "https://broth.itch.zone/butler",
+	GitHubAPI:  "https://api.github.com",
+	GitHubRepo: "ArchDevs/HyLauncher",
+	OnlineFix:  "https://hydralinks.pages.dev/sources/onlinefix.json",
+}
+
+// envVars maps the environment variables that override each endpoint
+var envVars = []struct {
+	name  string
+	field func(*Endpoints) *string
+}{
+	{"HYLAUNCHER_PATCHES_URL", func(e *Endpoints) *string { return &e.Patches }},
+	{"HYLAUNCHER_JRE_URL", func(e *Endpoints) *string { return &e.JRE }},
+	{"HYLAUNCHER_BUTLER_URL", func(e *Endpoints) *string { return &e.Butler }},
+	{"HYLAUNCHER_GITHUB_API_URL", func(e *Endpoints) *string { return &e.GitHubAPI }},
+	{"HYLAUNCHER_GITHUB_REPO", func(e *Endpoints) *string { return &e.GitHubRepo }},
+	{"HYLAUNCHER_ONLINEFIX_URL", func(e *Endpoints) *string { return &e.OnlineFix }},
+}
+
+var (
+	mu      sync.RWMutex
+	current = defaults
+)
+
+// Default returns the public servers
+func Default() Endpoints {
+	return defaults
+}
+
+// FromEnv returns the endpoints set in the environment, the rest is empty
+func FromEnv() Endpoints {
+	var e Endpoints
+	for _, v := range envVars {
+		*v.field(&e) = strings.TrimSpace(os.Getenv(v.name))
+	}
+	return e
+}
+
+// Merge returns e with every non-empty field of override applied
+func (e Endpoints) Merge(override Endpoints) Endpoints {
+	for _, v := range envVars {
+		if value := *v.field(&override); value != "" {
+			*v.field(&e) = value
+		}
+	}
+	return e
+}
+
+// Resolve applies the config and then the environment on top of the defaults
+func Resolve(cfg Endpoints) Endpoints {
+	return Default().Merge(cfg).Merge(FromEnv())
+}
+
+// Set makes e the endpoints every package uses from now on
+func Set(e Endpoints) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = Default().Merge(e)
+}
+
+// Get returns the endpoints in use
+func Get() Endpoints {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+func join(base string, elems ...string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.Join(elems, "/")
+}
+
+// PatchURL returns where the given .pwr file of a branch is served
+func (e Endpoints) PatchURL(osName, arch, branch, fileName string) string {
+	return join(e.Patches, osName, arch, branch, "0", fileName)
+}
+
+// JREManifestURL returns the JRE manifest of a branch
+func (e Endpoints) JREManifestURL(branch string) string {
+	return join(e.JRE, branch, "jre.json")
+}
+
+// ButlerURL returns the latest butler archive for a platform
+func (e Endpoints) ButlerURL(osName, arch string) string {
+	return join(e.Butler, fmt.Sprintf("%s-%s", osName, arch), "LATEST", "archive", "default")
+}
+
+// LatestReleaseURL returns the GitHub API URL of the latest launcher release
+func (e Endpoints) LatestReleaseURL() string {
+	return join(e.GitHubAPI, "repos", strings.Trim(e.GitHubRepo, "/"), "releases", "latest")
+}
+
+// ReleasesURL returns the GitHub API URL listing the launcher releases, newest first
+func (e Endpoints) ReleasesURL() string {
+	return join(e.GitHubAPI, "repos", strings.Trim(e.GitHubRepo, "/"), "releases")
+}
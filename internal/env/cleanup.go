@@ -6,28 +6,35 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 func CleanupLauncher(request model.InstanceModel) error {
 	cacheDir := GetCacheDir()
 
-	if err := cleanDirectoryWithFileExentsions(cacheDir, []string{".pwr", ".zip", ".tar.gz"}); err != nil {
-		fmt.Println("Warning: failed to clean cache:", err)
+	// Patches of builds newer than the installed one are background pre-downloads
+	keep := func(name string) bool {
+		build, err := strconv.Atoi(strings.TrimSuffix(name, ".pwr"))
+		return err == nil && filepath.Ext(name) == ".pwr" && build > request.BuildVersion
+	}
+
+	if err := cleanDirectoryWithFileExentsions(cacheDir, []string{".pwr", ".zip", ".gz", ".xz", ".zst", ".bz2", ".7z"}, keep); err != nil {
+		logger.Warn("failed to clean cache: %v", err)
 	}
 
 	gameLatest := filepath.Join(GetInstance(request.InstanceID), request.Branch, strconv.Itoa(request.BuildVersion))
 	if err := cleanIncompleteGame(gameLatest); err != nil {
-		fmt.Println("Warning: failed to clean game directory:", err)
+		logger.Warn("failed to clean game directory: %v", err)
 	}
 
 	stagingDir := filepath.Join(gameLatest, "staging-temp")
 	if err := os.RemoveAll(stagingDir); err != nil {
-		fmt.Println("Warning: failed to remove staging dir:", err)
+		logger.Warn("failed to remove staging dir: %v", err)
 	}
 
 	// Clean up old launcher backup from updates
 	if err := cleanupLauncherBackup(); err != nil {
-		fmt.Println("Warning: failed to clean launcher backup:", err)
+		logger.Warn("failed to clean launcher backup: %v", err)
 	}
 
 	return nil
@@ -47,16 +54,16 @@ func cleanupLauncherBackup() error {
 	}
 
 	// Remove the backup
-	fmt.Println("Removing old launcher backup:", backup)
+	logger.Info("Removing old launcher backup: %s", backup)
 	if err := os.Remove(backup); err != nil {
 		return fmt.Errorf("failed to remove backup: %w", err)
 	}
 
-	fmt.Println("Old launcher backup removed successfully")
+	logger.Info("Old launcher backup removed successfully")
 	return nil
 }
 
-func cleanDirectoryWithFileExentsions(dir string, extensions []string) error {
+func cleanDirectoryWithFileExentsions(dir string, extensions []string, keep func(name string) bool) error {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		return nil
 	}
@@ -67,16 +74,16 @@ func cleanDirectoryWithFileExentsions(dir string, extensions []string) error {
 	}
 
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() || keep(entry.Name()) {
 			continue
 		}
 
 		for _, ext := range extensions {
 			if filepath.Ext(entry.Name()) == ext {
 				filePath := filepath.Join(dir, entry.Name())
-				fmt.Println("Removing incomplete download:", filePath)
+				logger.Info("Removing incomplete download: %s", filePath)
 				if err := os.Remove(filePath); err != nil {
-					fmt.Println("Warning: failed to remove", filePath, ":", err)
+					logger.Warn("failed to remove %s: %v", filePath, err)
 				}
 				break
 			}
@@ -100,7 +107,7 @@ func cleanDirectory(dir string) error {
 		path := filepath.Join(dir, entry.Name())
 
 		if err := os.RemoveAll(path); err != nil {
-			fmt.Println("Warning: failed to remove", path, ":", err)
+			logger.Warn("failed to remove %s: %v", path, err)
 		}
 	}
 
@@ -120,7 +127,7 @@ func cleanIncompleteGame(gameDir string) error {
 	clientPath := filepath.Join(gameDir, "Client", gameClient)
 	if _, err := os.Stat(clientPath); os.IsNotExist(err) {
 		// Game is incomplete, remove entire directory
-		fmt.Println("Incomplete game installation detected, cleaning up...")
+		logger.Info("Incomplete game installation detected, cleaning up...")
 		return cleanDirectory(gameDir)
 	}
 
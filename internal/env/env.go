@@ -5,8 +5,12 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+
+	"HyLauncher/pkg/logging"
 )
 
+var logger = logging.New("env")
+
 func GetOS() string {
 	switch runtime.GOOS {
 	case "windows":
@@ -2,8 +2,10 @@ package game
 
 import (
 	"HyLauncher/internal/env"
+	"HyLauncher/internal/progress"
 	"HyLauncher/pkg/archive"
 	"HyLauncher/pkg/fileutil"
+	"context"
 	"fmt"
 	"path/filepath"
 )
@@ -19,9 +21,47 @@ func CheckInstalled(branch string, buildVersion int) error {
 		return fmt.Errorf("server jar missing")
 	}
 
-	if err := archive.IsZipValid(filepath.Join(base, "Assets.zip")); err != nil {
+	// A full CRC check already passed for this exact file, skip the quick one
+	assets := filepath.Join(base, "Assets.zip")
+	if archive.IsZipVerified(assets, assetsVerifyCache(base)) {
+		return nil
+	}
+
+	if err := archive.IsZipValid(assets); err != nil {
+		return fmt.Errorf("assets.zip corrupted: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyAssets runs a full CRC check of Assets.zip. Unless force is set it is
+// skipped when the file has not changed since the last passed check.
+func VerifyAssets(ctx context.Context, branch string, buildVersion int, force bool, reporter *progress.Reporter) error {
+	base := env.GetGameDir(branch, buildVersion)
+
+	if reporter != nil {
+		reporter.Report(progress.StageVerify, 0, "Verifying game assets...")
+	}
+
+	err := archive.VerifyZip(ctx, filepath.Join(base, "Assets.zip"), archive.VerifyOptions{
+		CacheFile: assetsVerifyCache(base),
+		Force:     force,
+		OnProgress: func(p archive.Progress) {
+			if reporter != nil {
+				reporter.Report(progress.StageVerify, p.Percent(), "Verifying game assets...")
+			}
+		},
+	})
+	if err != nil {
 		return fmt.Errorf("assets.zip corrupted: %w", err)
 	}
 
+	if reporter != nil {
+		reporter.Report(progress.StageVerify, 100, "Game assets verified")
+	}
 	return nil
 }
+
+func assetsVerifyCache(gameDir string) string {
+	return filepath.Join(gameDir, ".assets-verified.json")
+}
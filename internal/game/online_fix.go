@@ -13,17 +13,18 @@ import (
 	"strings"
 	"time"
 
+	"HyLauncher/internal/endpoints"
 	"HyLauncher/internal/env"
 	"HyLauncher/internal/progress"
 	"HyLauncher/pkg/fileutil"
 	"HyLauncher/pkg/model"
+	"HyLauncher/pkg/network"
 
 	"github.com/anacrolix/torrent"
 	"github.com/mholt/archives"
 )
 
 const (
-	metadataURL    = "https://hydralinks.pages.dev/sources/onlinefix.json"
 	fixPassword    = "online-fix.me"
 	torrentTimeout = 30 * time.Minute
 	fixArchiveName = "Hytale_Fix_Repair.rar"
@@ -31,6 +32,14 @@ const (
 	gameIdentifier = "Hytale"
 )
 
+var httpClient network.ClientRef
+
+// SetHTTPClient sends the online fix metadata request through c, nil restores
+// the default client
+func SetHTTPClient(c *http.Client) {
+	httpClient.Set(c)
+}
+
 type OnlineFixIndex struct {
 	Name      string     `json:"name"`
 	Downloads []Download `json:"downloads"`
@@ -79,20 +88,24 @@ func ApplyOnlineFixWindows(ctx context.Context, gameDir string, reporter *progre
 }
 
 func fetchMagnetLink(ctx context.Context) (string, error) {
+	metadataURL := endpoints.Get().OnlineFix
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
 	if err != nil {
 		return "", err
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Get().Do(req)
 	if err != nil {
-		return "", err
+		return "", network.Classify(metadataURL, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("bad status: %s", resp.Status)
+		return "", network.NewHTTPStatusError(resp)
 	}
 
 	var index OnlineFixIndex
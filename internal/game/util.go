@@ -30,3 +30,15 @@ func isWayland() bool {
 	return waylandDisplay != "" || sessionType == "wayland"
 }
 
+// SessionType returns the Linux desktop session the game starts in,
+// "wayland", "x11", another XDG_SESSION_TYPE value or "" when unknown
+func SessionType() string {
+	if isWayland() {
+		return "wayland"
+	}
+	if os.Getenv("DISPLAY") != "" {
+		return "x11"
+	}
+	return os.Getenv("XDG_SESSION_TYPE")
+}
+
@@ -1,29 +1,26 @@
 package java
 
 import (
+	"HyLauncher/internal/progress"
 	"HyLauncher/pkg/archive"
-	"fmt"
+	"context"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
-func extractJRE(archivePath, destDir string) error {
+func extractJRE(ctx context.Context, archivePath, destDir string, reporter *progress.Reporter) error {
 	_ = os.RemoveAll(destDir)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
 		return err
 	}
 
-	switch {
-	case strings.HasSuffix(archivePath, ".zip"):
-		return archive.ExtractZip(archivePath, destDir)
+	scaler := progress.NewScaler(reporter, progress.StageJRE, 95, 98)
+	extractor := archive.NewExtractor(archive.DefaultOptions(), func(p archive.Progress) {
+		scaler.ReportWithFile(progress.StageJRE, p.Percent(), "Extracting JRE", p.Current)
+	})
 
-	case strings.HasSuffix(archivePath, ".tar.gz"):
-		return archive.ExtractTarGz(archivePath, destDir)
-
-	default:
-		return fmt.Errorf("unsupported archive format: %s", archivePath)
-	}
+	// The format is detected from the file itself, manifest URLs do not always end in a known suffix
+	return extractor.Extract(ctx, archivePath, destDir)
 }
 
 func flattenJREDir(jreLatest string) error {
@@ -0,0 +1,203 @@
+package java
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"HyLauncher/internal/env"
+	"HyLauncher/pkg/fileutil"
+)
+
+// JREInfo describes an installed JRE version and what still uses it
+type JREInfo struct {
+	Version      string   `json:"version"`
+	Size         int64    `json:"size"`
+	ReferencedBy []string `json:"referencedBy"`
+}
+
+// CleanupResult is returned after unused JREs have been removed
+type CleanupResult struct {
+	Removed []string `json:"removed"`
+	Freed   int64    `json:"freed"`
+}
+
+// usage maps an installed game build ("branch/build") to the JRE version it runs on
+type usage map[string]string
+
+var usageMu sync.Mutex
+
+func usagePath() string {
+	return filepath.Join(env.GetJREDir(), "usage.json")
+}
+
+func loadUsage() (usage, error) {
+	data, err := os.ReadFile(usagePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage{}, nil
+		}
+		return nil, err
+	}
+
+	u := usage{}
+	if err := json.Unmarshal(data, &u); err != nil {
+		// A broken usage file only means builds get re-recorded on next launch
+		return usage{}, nil
+	}
+	return u, nil
+}
+
+func saveUsage(u usage) error {
+	if err := os.MkdirAll(env.GetJREDir(), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(usagePath(), data, 0644)
+}
+
+// RecordUsage remembers that a game build runs on the given JRE version
+func RecordUsage(branch string, build int, version string) error {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	u, err := loadUsage()
+	if err != nil {
+		return err
+	}
+
+	key := branch + "/" + strconv.Itoa(build)
+	if u[key] == version {
+		return nil
+	}
+
+	u[key] = version
+	return saveUsage(u)
+}
+
+// buildReferences returns JRE version -> builds using it, dropping builds that are no longer installed
+func buildReferences() (map[string][]string, error) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	u, err := loadUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string][]string)
+	changed := false
+
+	for key, version := range u {
+		branch, buildStr, ok := strings.Cut(key, "/")
+		build, convErr := strconv.Atoi(buildStr)
+		if !ok || convErr != nil || !fileutil.FileExists(env.GetGameDir(branch, build)) {
+			delete(u, key)
+			changed = true
+			continue
+		}
+		refs[version] = append(refs[version], "build:"+key)
+	}
+
+	if changed {
+		if err := saveUsage(u); err != nil {
+			return nil, err
+		}
+	}
+
+	return refs, nil
+}
+
+// ListJREs returns every installed JRE with the installed builds that use it.
+// Versions in pinned are always referenced, keyed by version with a reason per referrer.
+func ListJREs(pinned map[string][]string) ([]JREInfo, error) {
+	entries, err := os.ReadDir(env.GetJREDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []JREInfo{}, nil
+		}
+		return nil, err
+	}
+
+	refs, err := buildReferences()
+	if err != nil {
+		return nil, fmt.Errorf("read jre usage: %w", err)
+	}
+
+	for version, reasons := range pinned {
+		refs[version] = append(refs[version], reasons...)
+	}
+
+	jres := make([]JREInfo, 0, len(entries))
+	for _, entry := range entries {
+		// Skip usage.json and half-extracted "<version>.tmp" directories
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+
+		version := entry.Name()
+		size, err := fileutil.DirSize(GetJREVersionDir(version))
+		if err != nil {
+			return nil, fmt.Errorf("measure jre %s: %w", version, err)
+		}
+
+		referencedBy := refs[version]
+		sort.Strings(referencedBy)
+		if referencedBy == nil {
+			referencedBy = []string{}
+		}
+
+		jres = append(jres, JREInfo{
+			Version:      version,
+			Size:         size,
+			ReferencedBy: referencedBy,
+		})
+	}
+
+	return jres, nil
+}
+
+// UnusedJREs returns the installed JREs nothing references anymore
+func UnusedJREs(pinned map[string][]string) ([]JREInfo, error) {
+	jres, err := ListJREs(pinned)
+	if err != nil {
+		return nil, err
+	}
+
+	unused := make([]JREInfo, 0)
+	for _, jre := range jres {
+		if len(jre.ReferencedBy) == 0 {
+			unused = append(unused, jre)
+		}
+	}
+	return unused, nil
+}
+
+// RemoveUnusedJREs deletes every JRE that is not referenced by an installed build or pinned
+func RemoveUnusedJREs(pinned map[string][]string) (*CleanupResult, error) {
+	unused, err := UnusedJREs(pinned)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CleanupResult{Removed: []string{}}
+	for _, jre := range unused {
+		logger.Info("Removing unused JRE %s (%d bytes)", jre.Version, jre.Size)
+		if err := os.RemoveAll(GetJREVersionDir(jre.Version)); err != nil {
+			return result, fmt.Errorf("remove jre %s: %w", jre.Version, err)
+		}
+		result.Removed = append(result.Removed, jre.Version)
+		result.Freed += jre.Size
+	}
+
+	return result, nil
+}
@@ -10,12 +10,17 @@ import (
 	"runtime"
 	"time"
 
+	"HyLauncher/internal/endpoints"
 	"HyLauncher/internal/env"
 	"HyLauncher/internal/progress"
 	"HyLauncher/pkg/download"
 	"HyLauncher/pkg/fileutil"
+	"HyLauncher/pkg/logging"
+	"HyLauncher/pkg/network"
 )
 
+var logger = logging.New("java")
+
 var (
 	ErrJavaNotFound = fmt.Errorf("java not found")
 	ErrJavaBroken   = fmt.Errorf("java broken")
@@ -35,14 +40,35 @@ func GetJREVersionDir(version string) string {
 	return filepath.Join(env.GetJREDir(), version)
 }
 
+var httpClient network.ClientRef
+
+// SetHTTPClient sends the manifest requests through c, nil restores the
+// default client
+func SetHTTPClient(c *http.Client) {
+	httpClient.Set(c)
+}
+
 func FetchJREManifest(branch string) (*JREJSON, error) {
-	url := fmt.Sprintf("https://launcher.hytale.com/version/%s/jre.json", branch)
-	resp, err := http.Get(url)
+	url := endpoints.Get().JREManifestURL(branch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+
+	resp, err := httpClient.Get().Do(req)
+	if err != nil {
+		return nil, network.Classify(url, err)
+	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, network.NewHTTPStatusError(resp)
+	}
+
 	var jreData JREJSON
 	if err := json.NewDecoder(resp.Body).Decode(&jreData); err != nil {
 		return nil, err
@@ -88,7 +114,7 @@ func EnsureJRE(ctx context.Context, branch string, reporter *progress.Reporter)
 	arch := env.GetArch()
 	cacheDir := env.GetCacheDir()
 
-	if err := downloadAndInstallJRE(manifest, jreDir, cacheDir, osName, arch, reporter); err != nil {
+	if err := downloadAndInstallJRE(ctx, manifest, jreDir, cacheDir, osName, arch, reporter); err != nil {
 		_ = os.RemoveAll(jreDir)
 		return err
 	}
@@ -99,7 +125,7 @@ func EnsureJRE(ctx context.Context, branch string, reporter *progress.Reporter)
 	return nil
 }
 
-func downloadAndInstallJRE(manifest *JREJSON, jreDir, cacheDir, osName, arch string, reporter *progress.Reporter) error {
+func downloadAndInstallJRE(ctx context.Context, manifest *JREJSON, jreDir, cacheDir, osName, arch string, reporter *progress.Reporter) error {
 	osData, ok := manifest.DownloadURL[osName]
 	if !ok {
 		return fmt.Errorf("no JRE for OS: %s", osName)
@@ -118,20 +144,26 @@ func downloadAndInstallJRE(manifest *JREJSON, jreDir, cacheDir, osName, arch str
 
 	if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
 		scaler := progress.NewScaler(reporter, progress.StageJRE, 0, 90)
-		if err := download.DownloadWithReporter(cacheFile, platform.URL, fileName, reporter, progress.StageJRE, scaler); err != nil {
+		job := download.Job{
+			Kind:     download.KindJRE,
+			Name:     fileName,
+			URL:      platform.URL,
+			Dest:     cacheFile,
+			Expected: download.Expected{SHA256: platform.SHA256},
+		}
+		if err := download.Download(ctx, job, reporter, progress.StageJRE, scaler); err != nil {
+			_ = os.Remove(cacheFile)
+			return err
+		}
+	} else {
+		// The cached archive may be from an older, damaged download
+		if reporter != nil {
+			reporter.Report(progress.StageJRE, 92, "Verifying JRE integrity")
+		}
+		if err := fileutil.VerifySHA256(cacheFile, platform.SHA256); err != nil {
 			_ = os.Remove(cacheFile)
 			return err
 		}
-	} else if reporter != nil {
-		reporter.Report(progress.StageJRE, 90, "JRE archive cached")
-	}
-
-	if reporter != nil {
-		reporter.Report(progress.StageJRE, 92, "Verifying JRE integrity")
-	}
-	if err := fileutil.VerifySHA256(cacheFile, platform.SHA256); err != nil {
-		_ = os.Remove(cacheFile)
-		return err
 	}
 
 	tempDir := jreDir + ".tmp"
@@ -141,7 +173,7 @@ func downloadAndInstallJRE(manifest *JREJSON, jreDir, cacheDir, osName, arch str
 		reporter.Report(progress.StageJRE, 95, "Extracting JRE")
 	}
 
-	if err := extractJRE(cacheFile, tempDir); err != nil {
+	if err := extractJRE(ctx, cacheFile, tempDir, reporter); err != nil {
 		_ = os.RemoveAll(tempDir)
 		return err
 	}
@@ -187,16 +219,39 @@ func VerifyJRE(branch string) error {
 }
 
 func GetJavaExec(branch string) (string, error) {
+	_, javaBin, err := ResolveJRE(branch, "")
+	return javaBin, err
+}
+
+// VerifyInstalled reports whether JRE version is installed and runs
+func VerifyInstalled(version string) error {
+	return verifyJREVersion(version)
+}
+
+// ResolveJRE returns the JRE version and java executable to use for a branch.
+// A non-empty override pins an installed version instead of the manifest one.
+// EnsureJRE only installs the manifest version, so a pin that is missing or
+// broken falls back to it rather than failing every launch.
+func ResolveJRE(branch, override string) (string, string, error) {
+	if override != "" {
+		err := verifyJREVersion(override)
+		if err == nil {
+			return override, getJavaExecutablePathForVersion(override), nil
+		}
+		logger.Warn("pinned JRE %s is unusable, using the %s manifest version: %v", override, branch, err)
+	}
+
 	manifest, err := FetchJREManifest(branch)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	if err := verifyJREVersion(manifest.Version); err != nil {
-		return "", err
+	version := manifest.Version
+	if err := verifyJREVersion(version); err != nil {
+		return "", "", err
 	}
 
-	return getJavaExecutablePathForVersion(manifest.Version), nil
+	return version, getJavaExecutablePathForVersion(version), nil
 }
 
 func getJavaExecutablePathForVersion(version string) string {
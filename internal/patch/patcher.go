@@ -8,13 +8,17 @@ import (
 	"path/filepath"
 	"runtime"
 
+	"HyLauncher/internal/endpoints"
 	"HyLauncher/internal/env"
 	"HyLauncher/internal/platform"
 	"HyLauncher/internal/progress"
 	"HyLauncher/pkg/download"
+	"HyLauncher/pkg/logging"
 	"HyLauncher/pkg/model"
 )
 
+var logger = logging.New("patch")
+
 func ApplyPWR(ctx context.Context, pwrFile string, request model.InstanceModel, reporter *progress.Reporter) error {
 	gameDir := env.GetGameDir(request.Branch, request.BuildVersion)
 	stagingDir := filepath.Join(gameDir, ".staging-temp")
@@ -24,7 +28,8 @@ func ApplyPWR(ctx context.Context, pwrFile string, request model.InstanceModel,
 
 	butlerPath, err := GetButlerExec()
 	if err != nil {
-		fmt.Println("Can not get butler: %w", err)
+		logger.Error("cannot get butler: %v", err)
+		return fmt.Errorf("get butler: %w", err)
 	}
 
 	cmd := exec.CommandContext(ctx, butlerPath,
@@ -56,6 +61,20 @@ func ApplyPWR(ctx context.Context, pwrFile string, request model.InstanceModel,
 	return nil
 }
 
+// pwrFileName is the name of the patch to targetVer, on the server and in the cache
+func pwrFileName(targetVer int) string {
+	return fmt.Sprintf("%d.pwr", targetVer)
+}
+
+// DropStalePWR removes queued patch downloads for builds other than latest,
+// such as one left over from a previous run for a build since superseded
+func DropStalePWR(latest int) {
+	current := filepath.Join(env.GetCacheDir(), pwrFileName(latest))
+	download.Prune(download.KindPWR, func(job download.Job) bool {
+		return job.Dest == current
+	})
+}
+
 func DownloadPWR(ctx context.Context, branch string, targetVer int, reporter *progress.Reporter) (string, error) {
 	cacheDir := env.GetCacheDir()
 	_ = os.MkdirAll(cacheDir, 0755)
@@ -63,7 +82,7 @@ func DownloadPWR(ctx context.Context, branch string, targetVer int, reporter *pr
 	osName := runtime.GOOS
 	arch := runtime.GOARCH
 
-	fileName := fmt.Sprintf("%d.pwr", targetVer)
+	fileName := pwrFileName(targetVer)
 	dest := filepath.Join(cacheDir, fileName)
 	tempDest := dest + ".tmp"
 
@@ -74,14 +93,15 @@ func DownloadPWR(ctx context.Context, branch string, targetVer int, reporter *pr
 		return dest, nil
 	}
 
-	url := fmt.Sprintf("https://game-patches.hytale.com/patches/%s/%s/%s/0/%s",
-		osName, arch, branch, fileName)
+	url := endpoints.Get().PatchURL(osName, arch, branch, fileName)
 
 	reporter.Report(progress.StagePWR, 0, "Downloading PWR file...")
 
 	scaler := progress.NewScaler(reporter, progress.StagePWR, 0, 100)
 
-	if err := download.DownloadWithReporter(dest, url, fileName, reporter, progress.StagePWR, scaler); err != nil {
+	// Patches have no published digest, the download is only held to the length the server announces
+	job := download.Job{Kind: download.KindPWR, Name: fileName, URL: url, Dest: dest}
+	if err := download.Download(ctx, job, reporter, progress.StagePWR, scaler); err != nil {
 		_ = os.Remove(tempDest)
 		return "", err
 	}
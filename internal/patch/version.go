@@ -6,6 +6,9 @@ import (
 	"runtime"
 	"sync"
 	"time"
+
+	"HyLauncher/internal/endpoints"
+	"HyLauncher/pkg/network"
 )
 
 type VersionCheckResult struct {
@@ -71,7 +74,7 @@ func ClearVersionCache() {
 }
 
 func checkVersion(branch string) VersionCheckResult {
-	client := createRobustClient()
+	client := probeClient(10 * time.Second)
 
 	baseVersion := findBaseVersion(client, branch)
 	if baseVersion == 0 {
@@ -84,20 +87,23 @@ func checkVersion(branch string) VersionCheckResult {
 	return VersionCheckResult{LatestVersion: latestVersion}
 }
 
-func createRobustClient() *http.Client {
-	return &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			Proxy:                 http.ProxyFromEnvironment,
-			MaxIdleConns:          10,
-			IdleConnTimeout:       30 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		},
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
+var httpClient network.ClientRef
+
+// SetHTTPClient sends the patch server probes through c, nil restores the
+// default client
+func SetHTTPClient(c *http.Client) {
+	httpClient.Set(c)
+}
+
+// probeClient is the injected client with a short timeout. A redirect means
+// the build is missing, so it is not followed.
+func probeClient(timeout time.Duration) *http.Client {
+	client := *httpClient.Get()
+	client.Timeout = timeout
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
 	}
+	return &client
 }
 
 func findBaseVersion(client *http.Client, branch string) int {
@@ -170,8 +176,7 @@ func binarySearch(client *http.Client, branch string, low, high int) int {
 }
 
 func versionExists(client *http.Client, branch string, version int) bool {
-	url := fmt.Sprintf("https://game-patches.hytale.com/patches/%s/%s/%s/0/%d.pwr",
-		runtime.GOOS, runtime.GOARCH, branch, version)
+	url := endpoints.Get().PatchURL(runtime.GOOS, runtime.GOARCH, branch, fmt.Sprintf("%d.pwr", version))
 
 	resp, err := client.Head(url)
 	time.Sleep(200 * time.Millisecond)
@@ -180,12 +185,7 @@ func versionExists(client *http.Client, branch string, version int) bool {
 }
 
 func VerifyVersionExists(branch string, version int) error {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	client := probeClient(5 * time.Second)
 
 	if versionExists(client, branch, version) {
 		return nil
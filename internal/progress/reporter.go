@@ -2,6 +2,9 @@ package progress
 
 import (
 	"context"
+	"sync"
+
+	"HyLauncher/pkg/trace"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -31,11 +34,16 @@ type Data struct {
 	Speed       string  `json:"speed"`
 	Downloaded  int64   `json:"downloaded"`
 	Total       int64   `json:"total"`
+	Operation   string  `json:"operation,omitempty"` // Trace ID of the operation reporting
 }
 
 // Reporter handles all progress reporting to the frontend
 type Reporter struct {
 	ctx context.Context
+
+	mu        sync.Mutex
+	lastStage Stage
+	lastMsg   string
 }
 
 // New creates a new progress reporter
@@ -43,13 +51,42 @@ func New(ctx context.Context) *Reporter {
 	return &Reporter{ctx: ctx}
 }
 
+// WithContext returns a reporter for the operation running in ctx, its
+// updates carry the operation ID and are recorded in its trace. ctx must
+// derive from the context the reporter was created with.
+func (p *Reporter) WithContext(ctx context.Context) *Reporter {
+	if p == nil {
+		return nil
+	}
+	return New(ctx)
+}
+
+// emit sends data to the frontend. Stage and message changes are recorded in
+// the operation trace, percentage updates are too frequent to keep.
+func (p *Reporter) emit(data Data) {
+	if span := trace.FromContext(p.ctx); span != nil {
+		data.Operation = span.TraceID
+
+		p.mu.Lock()
+		changed := data.Stage != p.lastStage || data.Message != p.lastMsg
+		p.lastStage, p.lastMsg = data.Stage, data.Message
+		p.mu.Unlock()
+
+		if changed {
+			span.Record("progress", string(data.Stage)+": "+data.Message)
+		}
+	}
+
+	runtime.EventsEmit(p.ctx, "progress-update", data)
+}
+
 // Report sends a progress update to the frontend
 func (p *Reporter) Report(stage Stage, progress float64, message string) {
 	if p == nil || p.ctx == nil {
 		return
 	}
 
-	runtime.EventsEmit(p.ctx, "progress-update", Data{
+	p.emit(Data{
 		Stage:    stage,
 		Progress: progress,
 		Message:  message,
@@ -62,7 +99,7 @@ func (p *Reporter) ReportWithFile(stage Stage, progress float64, message string,
 		return
 	}
 
-	runtime.EventsEmit(p.ctx, "progress-update", Data{
+	p.emit(Data{
 		Stage:       stage,
 		Progress:    progress,
 		Message:     message,
@@ -75,7 +112,7 @@ func (p *Reporter) Reset() {
 		return
 	}
 
-	runtime.EventsEmit(p.ctx, "progress-update", Data{
+	p.emit(Data{
 		Stage:    StageIdle,
 		Progress: 0,
 		Message:  "",
@@ -88,7 +125,7 @@ func (p *Reporter) ReportDownload(stage Stage, progress float64, message string,
 		return
 	}
 
-	runtime.EventsEmit(p.ctx, "progress-update", Data{
+	p.emit(Data{
 		Stage:       stage,
 		Progress:    progress,
 		Message:     message,
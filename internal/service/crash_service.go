@@ -1,17 +1,22 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sync"
 	"time"
 
+	"HyLauncher/pkg/fileutil"
 	"HyLauncher/pkg/hyerrors"
+	"HyLauncher/pkg/logging"
+	"HyLauncher/pkg/trace"
 )
 
+var logger = logging.New("service")
+
 type Reporter struct {
 	rootDir    string
 	appVersion string
@@ -25,22 +30,24 @@ type CrashReport struct {
 	Error      *hyerrors.Error `json:"error"`
 	System     SystemInfo      `json:"system"`
 	Logs       []LogEntry      `json:"recent_logs,omitempty"`
+	Trace      []trace.Event   `json:"trace,omitempty"` // What the failed operation did before the error
 }
 
-type SystemInfo struct {
-	OS           string `json:"os"`
-	Arch         string `json:"arch"`
-	NumCPU       int    `json:"num_cpu"`
-	GoVersion    string `json:"go_version"`
-	NumGoroutine int    `json:"num_goroutine"`
-}
-
+// LogEntry is one line of errors.log
 type LogEntry struct {
+	ID        string            `json:"id,omitempty"`
 	Timestamp time.Time         `json:"timestamp"`
 	Severity  hyerrors.Severity `json:"severity"`
 	Category  hyerrors.Category `json:"category"`
+	Code      hyerrors.Code     `json:"code,omitempty"`
 	Message   string            `json:"message"`
 	Details   string            `json:"details,omitempty"`
+	Hint      string            `json:"hint,omitempty"`
+	Context   hyerrors.Context  `json:"context,omitempty"`
+	Stack     []hyerrors.Frame  `json:"stack,omitempty"`
+	TraceID   string            `json:"trace_id,omitempty"`
+
+	Occurrences int `json:"occurrences,omitempty"` // Repeats folded into this entry
 }
 
 func NewCrashReporter(rootDir, appVersion string) (*Reporter, error) {
@@ -54,13 +61,14 @@ func NewCrashReporter(rootDir, appVersion string) (*Reporter, error) {
 	}
 
 	if err := ensureErrorsFile(rootDir); err != nil {
-		fmt.Printf("%s\n", err)
+		logger.Error("%v", err)
 		return nil, err
 	}
 
-	hyerrors.RegisterHandlerFunc(r.handleError)
+	// Writing files must not hold up the code that reported the error
+	hyerrors.RegisterHandler(hyerrors.Async(hyerrors.HandlerFunc(r.handleError), errorQueueSize))
 
-	go r.cleanupOldReports(30 * 24 * time.Hour)
+	go r.cleanupOldReports()
 
 	return r, nil
 }
@@ -97,42 +105,41 @@ func (r *Reporter) handleError(err *hyerrors.Error) {
 }
 
 func (r *Reporter) logError(err *hyerrors.Error) {
+	if err.Occurrences > 1 {
+		logger.Error("[%s] %s (%d times)", err.Category, err.Message, err.Occurrences)
+	} else {
+		logger.Error("[%s] %s", err.Category, err.Message)
+	}
+	if err.Details != "" {
+		logger.Debug("[%s] %s", err.Category, err.Details)
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if err := ensureErrorsFile(r.rootDir); err != nil {
-		fmt.Printf("%s\n", err)
+		logger.Error("%v", err)
 	}
 
 	logPath := filepath.Join(r.logsDir(), "errors.log")
+	if rotateErr := logging.RotateIfLarger(logPath); rotateErr != nil {
+		logger.Warn("failed to rotate %s: %v", logPath, rotateErr)
+	}
+
 	f, fileErr := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if fileErr != nil {
-		fmt.Fprintf(os.Stderr, "failed to open log: %v\n", fileErr)
+		logger.Error("failed to open log: %v", fileErr)
 		return
 	}
 	defer f.Close()
 
-	entry := fmt.Sprintf("[%s] [%s] [%s] %s\n",
-		err.Timestamp.Format("2006-01-02 15:04:05"),
-		severityString(err.Severity),
-		err.Category,
-		err.Error(),
-	)
-
-	if err.Details != "" {
-		entry += fmt.Sprintf("  Details: %s\n", err.Details)
-	}
-
-	if len(err.Stack) > 0 {
-		entry += "  Stack:\n"
-		for _, frame := range err.Stack {
-			entry += fmt.Sprintf("    %s:%d %s\n", frame.File, frame.Line, frame.Function)
-		}
+	line, marshalErr := json.Marshal(newLogEntry(err))
+	if marshalErr != nil {
+		logger.Error("marshal log entry: %v", marshalErr)
+		return
 	}
 
-	entry += "---\n"
-
-	f.WriteString(entry)
+	f.Write(append(line, '\n'))
 }
 
 func (r *Reporter) saveCrashReport(err *hyerrors.Error) {
@@ -141,19 +148,16 @@ func (r *Reporter) saveCrashReport(err *hyerrors.Error) {
 		Timestamp:  time.Now(),
 		AppVersion: r.appVersion,
 		Error:      err,
-		System: SystemInfo{
-			OS:           runtime.GOOS,
-			Arch:         runtime.GOARCH,
-			NumCPU:       runtime.NumCPU(),
-			GoVersion:    runtime.Version(),
-			NumGoroutine: runtime.NumGoroutine(),
-		},
-		Logs: r.readRecentLogs(),
+		System:     collectSystemInfo(context.Background()),
+		Logs:       r.readRecentLogs(),
+	}
+	if err.TraceID != "" {
+		report.Trace = trace.Events(err.TraceID)
 	}
 
 	data, marshalErr := json.MarshalIndent(report, "", "  ")
 	if marshalErr != nil {
-		fmt.Fprintf(os.Stderr, "marshal crash report: %v\n", marshalErr)
+		logger.Error("marshal crash report: %v", marshalErr)
 		return
 	}
 
@@ -164,42 +168,50 @@ func (r *Reporter) saveCrashReport(err *hyerrors.Error) {
 	crashPath := filepath.Join(r.crashesDir(), filename)
 
 	if writeErr := os.WriteFile(crashPath, data, 0644); writeErr != nil {
-		fmt.Fprintf(os.Stderr, "write crash report: %v\n", writeErr)
+		logger.Error("write crash report: %v", writeErr)
 	}
 }
 
 func (r *Reporter) readRecentLogs() []LogEntry {
-	logPath := filepath.Join(r.logsDir(), "errors.log")
-	data, err := os.ReadFile(logPath)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := readLogTail(filepath.Join(r.logsDir(), "errors.log"), recentLogCount)
 	if err != nil {
+		logger.Error("read recent logs: %v", err)
 		return nil
 	}
-
-	if len(data) > 10000 {
-		data = data[len(data)-10000:]
-	}
-
-	return nil
+	return entries
 }
 
-func (r *Reporter) cleanupOldReports(maxAge time.Duration) {
+// cleanupOldReports applies the log retention once a day, reading it each
+// time so changed settings take effect without a restart
+func (r *Reporter) cleanupOldReports() {
 	ticker := time.NewTicker(24 * time.Hour)
 	defer ticker.Stop()
 
-	r.performCleanup(maxAge)
+	r.performCleanup(logging.CurrentRetention())
 
 	for range ticker.C {
-		r.performCleanup(maxAge)
+		r.performCleanup(logging.CurrentRetention())
 	}
 }
 
-func (r *Reporter) performCleanup(maxAge time.Duration) {
+func (r *Reporter) performCleanup(retention logging.Retention) {
+	if err := logging.Prune(r.logsDir(), retention); err != nil {
+		logger.Warn("failed to prune logs: %v", err)
+	}
+
+	if retention.MaxAgeDays <= 0 {
+		return
+	}
+
 	entries, err := os.ReadDir(r.crashesDir())
 	if err != nil {
 		return
 	}
 
-	cutoff := time.Now().Add(-maxAge)
+	cutoff := time.Now().Add(-retention.MaxAge())
 
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -248,6 +260,28 @@ func (r *Reporter) GetCrashReports() ([]CrashReport, error) {
 	return reports, nil
 }
 
+// DiskUsage is how much space logs and crash reports take, in bytes
+type DiskUsage struct {
+	Logs    int64 `json:"logs"`
+	Crashes int64 `json:"crashes"`
+}
+
+// DiskUsage measures the logs and crashes directories
+func (r *Reporter) DiskUsage() (DiskUsage, error) {
+	logs, err := fileutil.DirSize(r.logsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return DiskUsage{}, err
+	}
+
+	crashes, err := fileutil.DirSize(r.crashesDir())
+	if err != nil && !os.IsNotExist(err) {
+		return DiskUsage{}, err
+	}
+
+	return DiskUsage{Logs: logs, Crashes: crashes}, nil
+}
+
+// GetLogs returns errors.log formatted for reading
 func (r *Reporter) GetLogs() (string, error) {
 	logPath := filepath.Join(r.logsDir(), "errors.log")
 	data, err := os.ReadFile(logPath)
@@ -257,7 +291,7 @@ func (r *Reporter) GetLogs() (string, error) {
 		}
 		return "", err
 	}
-	return string(data), nil
+	return formatLog(data), nil
 }
 
 func severityString(s hyerrors.Severity) string {
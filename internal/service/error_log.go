@@ -0,0 +1,161 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"HyLauncher/pkg/hyerrors"
+)
+
+const (
+	recentLogCount = 50        // Entries embedded in a crash report
+	logTailBytes   = 256 << 10 // How far back from the end readLogTail looks
+	errorQueueSize = 64        // Reported errors that may wait for the reporter
+)
+
+func newLogEntry(err *hyerrors.Error) LogEntry {
+	return LogEntry{
+		ID:        err.ID,
+		Timestamp: err.Timestamp,
+		Severity:  err.Severity,
+		Category:  err.Category,
+		Code:      err.Code,
+		Message:   err.Message,
+		Details:   err.Details,
+		Hint:      err.Hint,
+		Context:   err.Context,
+		Stack:     err.Stack,
+		TraceID:   err.TraceID,
+
+		Occurrences: err.Occurrences,
+	}
+}
+
+// FormatLogEntry renders an entry the way errors.log looked before it
+// switched to JSON lines
+func FormatLogEntry(e LogEntry) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "[%s] [%s] [%s] %s\n",
+		e.Timestamp.Format("2006-01-02 15:04:05"),
+		severityString(e.Severity),
+		e.Category,
+		e.Message,
+	)
+
+	if e.Occurrences > 1 {
+		fmt.Fprintf(&b, "  Repeated: %d times\n", e.Occurrences)
+	}
+	if e.Code != "" {
+		fmt.Fprintf(&b, "  Code: %s\n", e.Code)
+	}
+	if e.TraceID != "" {
+		fmt.Fprintf(&b, "  Trace: %s\n", e.TraceID)
+	}
+	if e.Details != "" {
+		fmt.Fprintf(&b, "  Details: %s\n", e.Details)
+	}
+	if e.Hint != "" {
+		fmt.Fprintf(&b, "  Hint: %s\n", e.Hint)
+	}
+	for _, key := range slices.Sorted(maps.Keys(e.Context)) {
+		fmt.Fprintf(&b, "  %s: %v\n", key, e.Context[key])
+	}
+
+	if len(e.Stack) > 0 {
+		b.WriteString("  Stack:\n")
+		for _, frame := range e.Stack {
+			fmt.Fprintf(&b, "    %s:%d %s\n", frame.File, frame.Line, frame.Function)
+		}
+	}
+
+	b.WriteString("---\n")
+	return b.String()
+}
+
+// formatLog turns a JSON lines log into readable text. Lines written by older
+// versions are not JSON and are kept as they are.
+func formatLog(data []byte) string {
+	var b strings.Builder
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		var entry LogEntry
+		if json.Unmarshal(line, &entry) == nil {
+			b.WriteString(FormatLogEntry(entry))
+			continue
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// readTail returns at most the last size bytes of a file, starting at a
+// line boundary
+func readTail(path string, size int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := max(info.Size()-size, 0)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reading from the middle of the file starts inside a line
+	if offset > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return data, nil
+}
+
+// readLogTail returns the last n entries of a JSON lines log
+func readLogTail(path string, n int) ([]LogEntry, error) {
+	data, err := readTail(path, logTailBytes)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entries := make([]LogEntry, 0, n)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var entry LogEntry
+		if len(line) == 0 || json.Unmarshal(line, &entry) != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
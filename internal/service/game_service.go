@@ -16,7 +16,9 @@ import (
 	"HyLauncher/internal/patch"
 	"HyLauncher/internal/progress"
 	"HyLauncher/pkg/fileutil"
+	"HyLauncher/pkg/logging"
 	"HyLauncher/pkg/model"
+	"HyLauncher/pkg/trace"
 )
 
 type GameService struct {
@@ -24,6 +26,13 @@ type GameService struct {
 	reporter *progress.Reporter
 
 	installMutex sync.Mutex
+
+	cancelMu      sync.Mutex
+	cancelInstall context.CancelFunc
+
+	preMu          sync.Mutex
+	cancelPre      context.CancelFunc
+	installWaiting int // Installs and verifies waiting for installMutex, pre-downloads yield to them
 }
 
 func NewGameService(ctx context.Context, reporter *progress.Reporter) *GameService {
@@ -33,7 +42,7 @@ func NewGameService(ctx context.Context, reporter *progress.Reporter) *GameServi
 func (s *GameService) VerifyGame(request model.InstanceModel) error {
 	s.reporter.Report(progress.StageVerify, 0, "Starting verifying game installation...")
 
-	if err := java.VerifyJRE(request.Branch); err != nil {
+	if _, _, err := java.ResolveJRE(request.Branch, request.JREVersion); err != nil {
 		return fmt.Errorf("verify jre: %w", err)
 	}
 
@@ -53,29 +62,43 @@ func (s *GameService) VerifyGame(request model.InstanceModel) error {
 	return nil
 }
 
-func (s *GameService) EnsureInstalled(ctx context.Context, request model.InstanceModel, reporter *progress.Reporter) error {
-	s.installMutex.Lock()
+// EnsureInstalled installs the newest build when the instance's build is
+// missing and returns the build that is ready to launch
+func (s *GameService) EnsureInstalled(ctx context.Context, request model.InstanceModel, reporter *progress.Reporter) (int, error) {
+	s.lockInstall()
 	defer s.installMutex.Unlock()
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.setCancel(cancel)
+	defer s.setCancel(nil)
+
 	if reporter != nil {
 		reporter.Report(progress.StageVerify, 0, "Checking for game updates")
 	}
 
 	if s.VerifyGame(request) == nil {
-		return nil
+		return request.BuildVersion, nil
 	}
 
 	latestVersion, err := s.fetchLatestVersion(ctx, request.Branch)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	patch.DropStalePWR(latestVersion)
+	logger.Ctx(ctx).Info("Installing build %d of %s", latestVersion, request.Branch)
 
-	if err := java.EnsureJRE(ctx, request.Branch, reporter); err != nil {
-		return fmt.Errorf("install jre: %w", err)
+	if err := step(ctx, "jre", func(ctx context.Context) error {
+		return java.EnsureJRE(ctx, request.Branch, reporter)
+	}); err != nil {
+		return 0, fmt.Errorf("install jre: %w", err)
 	}
 
-	if err := patch.EnsureButler(ctx, reporter); err != nil {
-		return fmt.Errorf("install butler: %w", err)
+	if err := step(ctx, "butler", func(ctx context.Context) error {
+		return patch.EnsureButler(ctx, reporter)
+	}); err != nil {
+		return 0, fmt.Errorf("install butler: %w", err)
 	}
 
 	if reporter != nil {
@@ -83,7 +106,103 @@ func (s *GameService) EnsureInstalled(ctx context.Context, request model.Instanc
 		reporter.Report(progress.StageComplete, 0, fmt.Sprintf("Found version %d", latestVersion))
 	}
 
-	return s.Install(ctx, latestVersion, request, reporter)
+	if err := step(ctx, "install", func(ctx context.Context) error {
+		return s.Install(ctx, latestVersion, request, reporter)
+	}); err != nil {
+		return 0, err
+	}
+	return latestVersion, nil
+}
+
+// lockInstall takes installMutex ahead of a running pre-download, which is
+// cancelled; the partial patch resumes next time
+func (s *GameService) lockInstall() {
+	s.preMu.Lock()
+	s.installWaiting++
+	if s.cancelPre != nil {
+		s.cancelPre()
+	}
+	s.preMu.Unlock()
+
+	s.installMutex.Lock()
+
+	s.preMu.Lock()
+	s.installWaiting--
+	s.preMu.Unlock()
+}
+
+// step runs fn as a child span of the operation in ctx
+func step(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	ctx, span := trace.Start(ctx, name)
+	err := fn(ctx)
+	span.End(err)
+	return err
+}
+
+// VerifyAssets runs an on-demand full integrity check of the installed game assets
+func (s *GameService) VerifyAssets(ctx context.Context, request model.InstanceModel) error {
+	s.lockInstall()
+	defer s.installMutex.Unlock()
+
+	return game.VerifyAssets(ctx, request.Branch, request.BuildVersion, true, s.reporter)
+}
+
+// CancelInstall stops a running EnsureInstalled at the next cancellation point
+func (s *GameService) CancelInstall() {
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+
+	if s.cancelInstall != nil {
+		s.cancelInstall()
+	}
+}
+
+func (s *GameService) setCancel(cancel context.CancelFunc) {
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+	s.cancelInstall = cancel
+}
+
+// PreDownload fetches the patch of the newest build on request's branch ahead
+// of time, so updating later only has to apply it. It gives way to
+// EnsureInstalled and VerifyAssets, which cancel it; the partial download
+// resumes next time.
+func (s *GameService) PreDownload(ctx context.Context, request model.InstanceModel) error {
+	if !s.installMutex.TryLock() {
+		return nil
+	}
+	defer s.installMutex.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.preMu.Lock()
+	if s.installWaiting > 0 {
+		s.preMu.Unlock()
+		return nil
+	}
+	s.cancelPre = cancel
+	s.preMu.Unlock()
+
+	defer func() {
+		s.preMu.Lock()
+		s.cancelPre = nil
+		s.preMu.Unlock()
+	}()
+
+	latestVersion, err := s.fetchLatestVersion(ctx, request.Branch)
+	if err != nil {
+		return err
+	}
+	patch.DropStalePWR(latestVersion)
+
+	if latestVersion <= request.BuildVersion {
+		return nil
+	}
+
+	logger.Info("Pre-downloading build %d of %s", latestVersion, request.Branch)
+	_, err = patch.DownloadPWR(ctx, request.Branch, latestVersion, nil)
+	return err
 }
 
 func (s *GameService) fetchLatestVersion(ctx context.Context, branch string) (int, error) {
@@ -109,11 +228,19 @@ func (s *GameService) fetchLatestVersion(ctx context.Context, branch string) (in
 	}
 }
 
+// Install downloads and applies latestVersion, the build PreDownload fetches
+// ahead of time, and records it as the instance's build
 func (s *GameService) Install(ctx context.Context, latestVersion int, request model.InstanceModel, reporter *progress.Reporter) error {
+	request.BuildVersion = latestVersion
+
 	gameDir := env.GetGameDir(request.Branch, request.BuildVersion)
 	clientPath := env.GetGameClientPath(request.Branch, request.BuildVersion)
 
-	pwrPath, err := patch.DownloadPWR(ctx, request.Branch, request.BuildVersion, reporter)
+	var pwrPath string
+	err := step(ctx, "download_patch", func(ctx context.Context) (err error) {
+		pwrPath, err = patch.DownloadPWR(ctx, request.Branch, request.BuildVersion, reporter)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("download patch: %w", err)
 	}
@@ -122,7 +249,9 @@ func (s *GameService) Install(ctx context.Context, latestVersion int, request mo
 		reporter.Report(progress.StagePatch, 0, "Applying game patch...")
 	}
 
-	if err := patch.ApplyPWR(ctx, pwrPath, request, reporter); err != nil {
+	if err := step(ctx, "apply_patch", func(ctx context.Context) error {
+		return patch.ApplyPWR(ctx, pwrPath, request, reporter)
+	}); err != nil {
 		return fmt.Errorf("apply patch: %w", err)
 	}
 
@@ -137,11 +266,21 @@ func (s *GameService) Install(ctx context.Context, latestVersion int, request mo
 		}
 	}
 
+	if err := step(ctx, "verify_assets", func(ctx context.Context) error {
+		return game.VerifyAssets(ctx, request.Branch, request.BuildVersion, true, reporter)
+	}); err != nil {
+		return fmt.Errorf("verify assets: %w", err)
+	}
+
 	config.UpdateInstance("default", func(cfg *config.InstanceConfig) error {
 		cfg.Build = request.BuildVersion
 		return nil
 	})
 
+	if err := recordJREUsage(request); err != nil {
+		logger.Warn("failed to record JRE usage: %v", err)
+	}
+
 	if runtime.GOOS == "windows" {
 		if reporter != nil {
 			reporter.Report(progress.StageOnlineFix, 0, "Applying online fix...")
@@ -160,13 +299,32 @@ func (s *GameService) Install(ctx context.Context, latestVersion int, request mo
 		reporter.Report(progress.StageComplete, 100, "Game installed successfully")
 	}
 
+	// Post-update maintenance: the new build may have moved to a newer JRE
+	if result, err := NewJREService().CleanupUnused(); err != nil {
+		logger.Warn("failed to clean up unused JREs: %v", err)
+	} else if len(result.Removed) > 0 {
+		logger.Info("Removed unused JREs %v, freed %d bytes", result.Removed, result.Freed)
+	}
+
 	return nil
 }
 
-func (s *GameService) Launch(playerName string, request model.InstanceModel) error {
-	if s.reporter != nil {
-		s.reporter.Reset()
-		s.reporter.Report(progress.StageLaunch, 0, "Launching game...")
+func recordJREUsage(request model.InstanceModel) error {
+	version, _, err := java.ResolveJRE(request.Branch, request.JREVersion)
+	if err != nil {
+		return err
+	}
+	return java.RecordUsage(request.Branch, request.BuildVersion, version)
+}
+
+func (s *GameService) Launch(ctx context.Context, playerName string, request model.InstanceModel) (err error) {
+	ctx, span := trace.Start(ctx, "launch")
+	defer func() { span.End(err) }()
+
+	reporter := s.reporter.WithContext(ctx)
+	if reporter != nil {
+		reporter.Reset()
+		reporter.Report(progress.StageLaunch, 0, "Launching game...")
 	}
 
 	// Game files are in shared directory
@@ -181,16 +339,20 @@ func (s *GameService) Launch(playerName string, request model.InstanceModel) err
 		}
 	}
 
-	if err := game.EnsureServerAndClientFix(context.Background(), request, nil); err != nil {
+	if err := game.EnsureServerAndClientFix(ctx, request, nil); err != nil {
 		return fmt.Errorf("apply game fixes: %w", err)
 	}
 
 	clientPath := env.GetGameClientPath(request.Branch, request.BuildVersion)
-	javaBin, err := java.GetJavaExec(request.Branch)
+	jreVersion, javaBin, err := java.ResolveJRE(request.Branch, request.JREVersion)
 	if err != nil {
 		return fmt.Errorf("find java: %w", err)
 	}
 
+	if err := java.RecordUsage(request.Branch, request.BuildVersion, jreVersion); err != nil {
+		logger.Warn("failed to record JRE usage: %v", err)
+	}
+
 	if runtime.GOOS == "darwin" {
 		_ = os.Chmod(clientPath, 0755)
 		_ = os.Chmod(javaBin, 0755)
@@ -212,16 +374,26 @@ func (s *GameService) Launch(playerName string, request model.InstanceModel) err
 		"--name", playerName,
 	)
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// The game inherits the file, so it can be closed once the process started
+	gameLog, err := logging.OpenFile("game.log")
+	if err != nil {
+		logger.Warn("failed to open game log: %v", err)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	} else {
+		defer gameLog.Close()
+		cmd.Stdout = gameLog
+		cmd.Stderr = gameLog
+	}
 
 	game.SetSDLVideoDriver(cmd)
 
-	fmt.Println(cmd)
+	logger.Ctx(ctx).Debug("Starting game: %s", cmd)
 
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("start game process: %w", err)
 	}
+	logger.Ctx(ctx).Info("Game started with pid %d", cmd.Process.Pid)
 
 	return nil
 }
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"HyLauncher/internal/config"
+	"HyLauncher/internal/env"
+	"HyLauncher/internal/java"
+	"HyLauncher/pkg/fileutil"
+)
+
+type JREService struct{}
+
+func NewJREService() *JREService {
+	return &JREService{}
+}
+
+// ListUnused returns installed JREs that no build or instance references, with their sizes
+func (s *JREService) ListUnused() ([]java.JREInfo, error) {
+	pinned, err := s.pinnedJREs()
+	if err != nil {
+		return nil, err
+	}
+	return java.UnusedJREs(pinned)
+}
+
+// CleanupUnused removes installed JREs that no build or instance references
+func (s *JREService) CleanupUnused() (*java.CleanupResult, error) {
+	pinned, err := s.pinnedJREs()
+	if err != nil {
+		return nil, err
+	}
+	return java.RemoveUnusedJREs(pinned)
+}
+
+// pinnedJREs collects versions that must survive cleanup: instance overrides and
+// the current manifest version of every branch an instance is on. It fails when a
+// manifest is unreachable, so nothing gets removed while offline.
+func (s *JREService) pinnedJREs() (map[string][]string, error) {
+	entries, err := os.ReadDir(env.GetInstancesDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	pinned := make(map[string][]string)
+	branches := make(map[string]struct{})
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		id := entry.Name()
+		if !fileutil.FileExists(filepath.Join(env.GetInstanceDir(id), "config.toml")) {
+			continue
+		}
+
+		cfg, err := config.LoadInstance(id)
+		if err != nil {
+			return nil, fmt.Errorf("load instance %s: %w", id, err)
+		}
+
+		branches[cfg.Branch] = struct{}{}
+		if cfg.JRE != "" {
+			pinned[cfg.JRE] = append(pinned[cfg.JRE], "instance:"+id)
+		}
+	}
+
+	for branch := range branches {
+		manifest, err := java.FetchJREManifest(branch)
+		if err != nil {
+			return nil, fmt.Errorf("fetch jre manifest for %s: %w", branch, err)
+		}
+		pinned[manifest.Version] = append(pinned[manifest.Version], "manifest:"+branch)
+	}
+
+	return pinned, nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"HyLauncher/internal/config"
+	"HyLauncher/internal/env"
+	"HyLauncher/internal/java"
+	"HyLauncher/pkg/archive"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	bundleLogBytes     = 2 << 20 // Tail of each log included in a support bundle
+	bundleCrashReports = 20      // Newest crash reports included
+	bundleTreeDepth    = 3       // Directory levels listed in tree.txt
+)
+
+// BundleInfo is environment.json in a support bundle
+type BundleInfo struct {
+	AppVersion string         `json:"app_version"`
+	CreatedAt  time.Time      `json:"created_at"`
+	System     SystemInfo     `json:"system"`
+	JREs       []java.JREInfo `json:"jres"` // With their size and the builds using them
+}
+
+// ExportSupportBundle writes a zip to dest with what we need to look into a
+// reported problem: recent logs, crash reports, redacted configs, installed
+// versions, a directory summary and system info. Secrets and the home
+// directory are scrubbed from everything in it.
+func (r *Reporter) ExportSupportBundle(ctx context.Context, dest string, cfg config.LauncherConfig) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	tmp := dest + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	scrub := newScrubber(cfg.Updates.GitHubToken, cfg.Proxy.Password)
+
+	err = r.writeBundle(ctx, f, cfg, scrub)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dest)
+}
+
+func (r *Reporter) writeBundle(ctx context.Context, f *os.File, cfg config.LauncherConfig, scrub *scrubber) error {
+	p, err := archive.NewPacker(f, archive.FormatZip, archive.PackOptions{})
+	if err != nil {
+		return err
+	}
+
+	add := func(name string, data []byte) error {
+		return p.AddBytes(name, scrub.bytes(data))
+	}
+
+	info, err := json.MarshalIndent(r.bundleInfo(ctx), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := add("environment.json", info); err != nil {
+		return err
+	}
+
+	launcherCfg, err := toml.Marshal(cfg.Redacted())
+	if err != nil {
+		return err
+	}
+	if err := add("config/launcher.toml", launcherCfg); err != nil {
+		return err
+	}
+
+	instances, _ := os.ReadDir(env.GetInstancesDir())
+	for _, entry := range instances {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(env.GetInstanceDir(entry.Name()), "config.toml"))
+		if err != nil {
+			continue
+		}
+		if err := add(path.Join("config", "instances", entry.Name()+".toml"), data); err != nil {
+			return err
+		}
+	}
+
+	logs, _ := os.ReadDir(r.logsDir())
+	for _, entry := range logs {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".log" {
+			continue
+		}
+		data, err := readTail(filepath.Join(r.logsDir(), entry.Name()), bundleLogBytes)
+		if err != nil {
+			continue
+		}
+		if err := add(path.Join("logs", entry.Name()), data); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range r.newestCrashReports(bundleCrashReports) {
+		data, err := os.ReadFile(filepath.Join(r.crashesDir(), name))
+		if err != nil {
+			continue
+		}
+		if err := add(path.Join("crashes", name), data); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := add("tree.txt", []byte(dirTree(r.rootDir, bundleTreeDepth))); err != nil {
+		return err
+	}
+
+	return p.Close()
+}
+
+func (r *Reporter) bundleInfo(ctx context.Context) BundleInfo {
+	info := BundleInfo{
+		AppVersion: r.appVersion,
+		CreatedAt:  time.Now(),
+		System:     collectSystemInfo(ctx),
+	}
+
+	jres, err := java.ListJREs(nil)
+	if err != nil {
+		logger.Warn("support bundle: list JREs: %v", err)
+	}
+	info.JREs = jres
+
+	return info
+}
+
+// newestCrashReports returns the names of the last n crash reports written
+func (r *Reporter) newestCrashReports(n int) []string {
+	entries, err := os.ReadDir(r.crashesDir())
+	if err != nil {
+		return nil
+	}
+
+	type report struct {
+		name    string
+		modTime time.Time
+	}
+	var reports []report
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		reports = append(reports, report{entry.Name(), info.ModTime()})
+	}
+
+	slices.SortFunc(reports, func(a, b report) int {
+		return b.modTime.Compare(a.modTime)
+	})
+
+	names := make([]string, 0, min(n, len(reports)))
+	for _, report := range reports[:min(n, len(reports))] {
+		names = append(names, report.name)
+	}
+	return names
+}
+
+// installedBuilds lists the game builds under the shared games directory
+func installedBuilds() []string {
+	builds := []string{}
+
+	branches, _ := os.ReadDir(env.GetSharedGamesDir())
+	for _, branch := range branches {
+		if !branch.IsDir() {
+			continue
+		}
+		versions, _ := os.ReadDir(filepath.Join(env.GetSharedGamesDir(), branch.Name()))
+		for _, version := range versions {
+			if version.IsDir() {
+				builds = append(builds, branch.Name()+"/"+version.Name())
+			}
+		}
+	}
+	return builds
+}
+
+// dirTree summarizes root down to depth levels, one directory per line with
+// its file count and size. Paths are relative, so it holds no home path.
+func dirTree(root string, depth int) string {
+	type stat struct {
+		files int
+		size  int64
+	}
+	stats := make(map[string]*stat)
+
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if rel == "." {
+			parts = nil
+		}
+
+		// Count the file in every listed directory above it
+		for i := 0; i <= min(len(parts), depth); i++ {
+			dir := path.Join(append([]string{"."}, parts[:i]...)...)
+			if stats[dir] == nil {
+				stats[dir] = &stat{}
+			}
+			stats[dir].files++
+			stats[dir].size += info.Size()
+		}
+		return nil
+	})
+
+	dirs := make([]string, 0, len(stats))
+	for dir := range stats {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+
+	var b strings.Builder
+	for _, dir := range dirs {
+		fmt.Fprintf(&b, "%-60s %8d files %10s\n", dir+"/", stats[dir].files, formatSize(stats[dir].size))
+	}
+	return b.String()
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// tokenPattern matches GitHub tokens and credentials in URLs that may have
+// ended up in a log
+var tokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b|://[^/\s:@]+:[^/\s@]+@`)
+
+// scrubber removes secrets and the home directory from bundle files
+type scrubber struct {
+	replacer *strings.Replacer
+}
+
+func newScrubber(secrets ...string) *scrubber {
+	var pairs []string
+	for _, secret := range secrets {
+		// Very short values would also match ordinary words
+		if len(secret) >= 4 {
+			pairs = append(pairs, secret, "[redacted]")
+		}
+	}
+
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		// As written, with forward slashes and escaped inside JSON
+		for _, form := range []string{
+			home,
+			filepath.ToSlash(home),
+			strings.ReplaceAll(home, `\`, `\\`),
+		} {
+			pairs = append(pairs, form, "~")
+		}
+	}
+
+	return &scrubber{replacer: strings.NewReplacer(pairs...)}
+}
+
+func (s *scrubber) bytes(data []byte) []byte {
+	out := s.replacer.Replace(string(data))
+	out = tokenPattern.ReplaceAllStringFunc(out, func(match string) string {
+		if strings.HasPrefix(match, "://") {
+			return "://[redacted]@"
+		}
+		return "[redacted]"
+	})
+	return []byte(out)
+}
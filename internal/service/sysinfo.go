@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"HyLauncher/internal/env"
+	"HyLauncher/internal/java"
+	"HyLauncher/internal/patch"
+)
+
+// SystemInfo describes the machine and installation a report comes from.
+// Fields a platform cannot tell are left empty.
+type SystemInfo struct {
+	OS           string `json:"os"`
+	Arch         string `json:"arch"`
+	NumCPU       int    `json:"num_cpu"`
+	GoVersion    string `json:"go_version"`
+	NumGoroutine int    `json:"num_goroutine"`
+
+	Distro        string `json:"distro,omitempty"`         // Such as "Ubuntu 24.04.1 LTS" or "Windows 11"
+	Kernel        string `json:"kernel,omitempty"`         // Kernel release or OS build
+	Session       string `json:"session,omitempty"`        // Linux desktop session, wayland or x11
+	InstallMethod string `json:"install_method,omitempty"` // How the launcher was installed, see installMethod
+	MemTotal      uint64 `json:"mem_total,omitempty"`      // Bytes
+	MemFree       uint64 `json:"mem_free,omitempty"`       // Bytes available to new processes
+	DiskFree      uint64 `json:"disk_free,omitempty"`      // Bytes free on the volume of the launcher folder
+
+	JREs   []string `json:"jres,omitempty"`   // Installed JRE versions
+	Butler string   `json:"butler,omitempty"` // butler --version, or why it failed
+	Builds []string `json:"builds,omitempty"` // Installed game builds as "branch/build"
+}
+
+// SystemCollector fills in the fields of SystemInfo it knows about. Each
+// platform has one in sysinfo_<os>.go.
+type SystemCollector interface {
+	Collect(ctx context.Context, info *SystemInfo)
+}
+
+var systemCollectors = []SystemCollector{
+	platformCollector{},
+	versionCollector{},
+}
+
+func collectSystemInfo(ctx context.Context) SystemInfo {
+	info := SystemInfo{
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		NumCPU:       runtime.NumCPU(),
+		GoVersion:    runtime.Version(),
+		NumGoroutine: runtime.NumGoroutine(),
+		DiskFree:     diskFree(existingParent(env.GetDefaultAppDir())),
+	}
+
+	for _, c := range systemCollectors {
+		c.Collect(ctx, &info)
+	}
+	return info
+}
+
+// existingParent returns path or the closest directory above it that
+// exists, the launcher folder is only created on first start
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// versionCollector records what the launcher has installed
+type versionCollector struct{}
+
+func (versionCollector) Collect(ctx context.Context, info *SystemInfo) {
+	jres, err := java.ListJREs(nil)
+	if err != nil {
+		logger.Warn("system info: list JREs: %v", err)
+	}
+	for _, jre := range jres {
+		info.JREs = append(info.JREs, jre.Version)
+	}
+
+	butler, err := patch.ButlerVersion(ctx)
+	if err != nil {
+		butler = err.Error()
+	}
+	info.Butler = butler
+
+	info.Builds = installedBuilds()
+}
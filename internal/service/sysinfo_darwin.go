@@ -0,0 +1,86 @@
+//go:build darwin
+
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+type platformCollector struct{}
+
+func (platformCollector) Collect(ctx context.Context, info *SystemInfo) {
+	info.InstallMethod = installMethod()
+
+	if out, err := exec.CommandContext(ctx, "sw_vers", "-productVersion").Output(); err == nil {
+		info.Distro = "macOS " + strings.TrimSpace(string(out))
+	}
+	if release, err := syscall.Sysctl("kern.osrelease"); err == nil {
+		info.Kernel = "Darwin " + release
+	}
+
+	if raw, err := syscall.Sysctl("hw.memsize"); err == nil {
+		// Sysctl returns the raw integer and drops its last byte when zero
+		b := make([]byte, 8)
+		copy(b, raw)
+		info.MemTotal = binary.LittleEndian.Uint64(b)
+	}
+	info.MemFree = vmFree(ctx)
+}
+
+var pageSizePattern = regexp.MustCompile(`page size of (\d+) bytes`)
+
+// vmFree returns the free, inactive and speculative memory vm_stat reports,
+// which macOS hands to new processes
+func vmFree(ctx context.Context) uint64 {
+	out, err := exec.CommandContext(ctx, "vm_stat").Output()
+	if err != nil {
+		return 0
+	}
+
+	pageSize := uint64(4096)
+	if m := pageSizePattern.FindSubmatch(out); m != nil {
+		if n, err := strconv.ParseUint(string(m[1]), 10, 64); err == nil {
+			pageSize = n
+		}
+	}
+
+	var pages uint64
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// Pages free:                               12345.
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Pages free", "Pages inactive", "Pages speculative":
+			n, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), "."), 10, 64)
+			if err == nil {
+				pages += n
+			}
+		}
+	}
+	return pages * pageSize
+}
+
+// installMethod tells an app bundle moved to Applications apart from one run
+// from wherever it was unpacked
+func installMethod() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "unknown"
+	}
+	if strings.HasPrefix(exe, "/Applications/") {
+		return "applications"
+	}
+	return "portable"
+}
@@ -0,0 +1,131 @@
+//go:build linux
+
+package service
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"HyLauncher/internal/game"
+)
+
+type platformCollector struct{}
+
+func (platformCollector) Collect(ctx context.Context, info *SystemInfo) {
+	info.Distro = osRelease()
+	info.Session = game.SessionType()
+	info.InstallMethod = installMethod()
+
+	var uts syscall.Utsname
+	if err := syscall.Uname(&uts); err == nil {
+		info.Kernel = utsString(uts.Release[:])
+	}
+
+	mem := readMeminfo()
+	info.MemTotal = mem["MemTotal"]
+	info.MemFree = mem["MemAvailable"]
+}
+
+// osRelease returns PRETTY_NAME from os-release, falling back to NAME VERSION
+func osRelease() string {
+	for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		fields := make(map[string]string)
+		for _, line := range strings.Split(string(data), "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if !ok {
+				continue
+			}
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			} else {
+				value = strings.Trim(value, `'`)
+			}
+			fields[key] = value
+		}
+
+		if fields["PRETTY_NAME"] != "" {
+			return fields["PRETTY_NAME"]
+		}
+		return strings.TrimSpace(fields["NAME"] + " " + fields["VERSION"])
+	}
+	return ""
+}
+
+// readMeminfo returns the /proc/meminfo values in bytes
+func readMeminfo() map[string]uint64 {
+	values := make(map[string]uint64)
+
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// MemTotal:       16318480 kB
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		values[key] = n
+	}
+	return values
+}
+
+// utsString converts a NUL terminated uname field, which is int8 or uint8
+// depending on the architecture
+func utsString[T int8 | uint8](chars []T) string {
+	b := make([]byte, 0, len(chars))
+	for _, c := range chars {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
+
+// installMethod tells sandboxed and packaged installs apart from a binary
+// run from wherever it was unpacked
+func installMethod() string {
+	switch {
+	case os.Getenv("FLATPAK_ID") != "":
+		return "flatpak"
+	case os.Getenv("SNAP") != "":
+		return "snap"
+	case os.Getenv("APPIMAGE") != "":
+		return "appimage"
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "unknown"
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err == nil && strings.HasPrefix(exe, "/usr/") {
+		// Installed by the package manager, as the PKGBUILD does
+		return "package"
+	}
+	return "portable"
+}
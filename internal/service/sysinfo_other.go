@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package service
+
+import "context"
+
+type platformCollector struct{}
+
+func (platformCollector) Collect(ctx context.Context, info *SystemInfo) {}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package service
+
+import "syscall"
+
+// diskFree returns the bytes available to the user on the volume of path
+func diskFree(path string) uint64 {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize)
+}
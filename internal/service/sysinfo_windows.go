@@ -0,0 +1,94 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+	procGetDiskFreeSpaceExW  = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procRtlGetVersion        = syscall.NewLazyDLL("ntdll.dll").NewProc("RtlGetVersion")
+)
+
+// memoryStatusEx is MEMORYSTATUSEX
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// osVersionInfo is OSVERSIONINFOW
+type osVersionInfo struct {
+	OSVersionInfoSize uint32
+	MajorVersion      uint32
+	MinorVersion      uint32
+	BuildNumber       uint32
+	PlatformID        uint32
+	CSDVersion        [128]uint16
+}
+
+type platformCollector struct{}
+
+func (platformCollector) Collect(ctx context.Context, info *SystemInfo) {
+	info.InstallMethod = installMethod()
+
+	mem := memoryStatusEx{Length: uint32(unsafe.Sizeof(memoryStatusEx{}))}
+	if ok, _, _ := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&mem))); ok != 0 {
+		info.MemTotal = mem.TotalPhys
+		info.MemFree = mem.AvailPhys
+	}
+
+	// RtlGetVersion reports the real version, GetVersionEx lies to
+	// programs without a compatibility manifest
+	ver := osVersionInfo{OSVersionInfoSize: uint32(unsafe.Sizeof(osVersionInfo{}))}
+	if status, _, _ := procRtlGetVersion.Call(uintptr(unsafe.Pointer(&ver))); status == 0 {
+		name := fmt.Sprintf("Windows %d", ver.MajorVersion)
+		if ver.MajorVersion == 10 && ver.BuildNumber >= 22000 {
+			// Windows 11 still reports itself as 10
+			name = "Windows 11"
+		}
+		info.Distro = name
+		info.Kernel = fmt.Sprintf("%d.%d.%d", ver.MajorVersion, ver.MinorVersion, ver.BuildNumber)
+	}
+}
+
+// diskFree returns the bytes available to the user on the volume of path
+func diskFree(path string) uint64 {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0
+	}
+
+	var free uint64
+	if ok, _, _ := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&free)), 0, 0); ok == 0 {
+		return 0
+	}
+	return free
+}
+
+// installMethod tells the NSIS installer, which puts an uninstaller next to
+// the launcher, apart from a copied executable
+func installMethod() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "unknown"
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(exe), "uninstall.exe")); err == nil {
+		return "installer"
+	}
+	return "portable"
+}
@@ -4,6 +4,7 @@ import (
 	"HyLauncher/internal/progress"
 	"HyLauncher/pkg/download"
 	"HyLauncher/pkg/fileutil"
+	"HyLauncher/pkg/logging"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,6 +13,8 @@ import (
 	"strings"
 )
 
+var logger = logging.New("updater")
+
 const versionJSONAsset = "version.json"
 
 type UpdateInfo struct {
@@ -35,9 +38,9 @@ type Asset struct {
 	Sha256 string `json:"sha256"`
 }
 
-// Checks if there is any new launcher update, returns Asset: url to download, sha256 hash
-func CheckUpdate(ctx context.Context, current string) (*Asset, string, error) {
-	info, err := fetchUpdateInfo(ctx)
+// Checks if there is any new launcher update on channel, returns Asset: url to download, sha256 hash
+func CheckUpdate(ctx context.Context, current string, channel download.Channel) (*Asset, string, error) {
+	info, err := fetchUpdateInfo(ctx, channel)
 	if err != nil {
 		return nil, "", err
 	}
@@ -45,20 +48,20 @@ func CheckUpdate(ctx context.Context, current string) (*Asset, string, error) {
 	currentClean := strings.TrimPrefix(strings.TrimSpace(current), "v")
 	latestClean := strings.TrimPrefix(strings.TrimSpace(info.Version), "v")
 
-	fmt.Printf("Current version: %s, Latest version: %s\n", current, info.Version)
+	logger.Info("Current version: %s, Latest version: %s", current, info.Version)
 
 	if currentClean == latestClean {
-		fmt.Println("Already on latest version")
+		logger.Info("Already on latest version")
 		return nil, "", nil
 	}
 
 	var asset *Asset
 	if runtime.GOOS == "windows" {
 		asset = &info.Windows.Amd64.Launcher
-		fmt.Printf("Update available for Windows: %s -> %s\n", current, info.Version)
+		logger.Info("Update available for Windows: %s -> %s", current, info.Version)
 	} else {
 		asset = &info.Linux.Amd64.Launcher
-		fmt.Printf("Update available for Linux: %s -> %s\n", current, info.Version)
+		logger.Info("Update available for Linux: %s -> %s", current, info.Version)
 	}
 
 	if asset.URL == "" {
@@ -69,8 +72,8 @@ func CheckUpdate(ctx context.Context, current string) (*Asset, string, error) {
 }
 
 // Get update-helper asset/info
-func GetHelperAsset(ctx context.Context) (*Asset, error) {
-	info, err := fetchUpdateInfo(ctx)
+func GetHelperAsset(ctx context.Context, channel download.Channel) (*Asset, error) {
+	info, err := fetchUpdateInfo(ctx, channel)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +93,7 @@ func GetHelperAsset(ctx context.Context) (*Asset, error) {
 }
 
 // Creates temp version json, downloads actual version json, reads actual info, returns atest update info
-func fetchUpdateInfo(ctx context.Context) (*UpdateInfo, error) {
+func fetchUpdateInfo(ctx context.Context, channel download.Channel) (*UpdateInfo, error) {
 	tempFile, err := fileutil.CreateTempFile("version-*.json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp file: %w", err)
@@ -98,7 +101,7 @@ func fetchUpdateInfo(ctx context.Context) (*UpdateInfo, error) {
 	defer os.Remove(tempFile)
 
 	// Download version.json
-	if err := download.DownloadLatestReleaseAsset(ctx, versionJSONAsset, tempFile, progress.StageUpdate, nil, nil); err != nil {
+	if err := download.DownloadReleaseAsset(ctx, channel, versionJSONAsset, tempFile, progress.StageUpdate, nil, nil); err != nil {
 		return nil, fmt.Errorf("failed to download version info: %w", err)
 	}
 
@@ -1,36 +1,45 @@
 package updater
 
 import (
+	"HyLauncher/internal/env"
 	"HyLauncher/internal/progress"
 	"HyLauncher/pkg/download"
-	"HyLauncher/pkg/fileutil"
 	"context"
 	"fmt"
+	"hash/crc32"
 	"os"
+	"path/filepath"
 )
 
-// Downloads latest launcher, returns path to temp file. If cant download deletes temp file
+// Downloads latest launcher, returns path to temp file. If cant download or sha256 does not match deletes temp file.
+// The temp name is derived from url, so a download the queue resumes after a restart lands in the same file.
 func DownloadTemp(
 	ctx context.Context,
 	url string,
+	sha256 string,
 	reporter *progress.Reporter,
 ) (string, error) {
 
-	tmpPath, err := fileutil.CreateTempFile("file-update-*")
-	if err != nil {
-		return "", err
-	}
+	sum := crc32.ChecksumIEEE([]byte(url))
+	tmpPath := filepath.Join(env.GetCacheDir(), fmt.Sprintf("file-update-%08x", sum))
 
 	reporter.Report(progress.StageUpdate, 0, "Downloading launcher update...")
 
 	scaler := progress.NewScaler(reporter, progress.StageUpdate, 0, 100)
 
-	if err := download.DownloadWithReporter(tmpPath, url, "launcher", reporter, progress.StageUpdate, scaler); err != nil {
+	job := download.Job{
+		Kind:     download.KindLauncher,
+		Name:     "launcher",
+		URL:      url,
+		Dest:     tmpPath,
+		Expected: download.Expected{SHA256: sha256},
+	}
+	if err := download.Download(ctx, job, reporter, progress.StageUpdate, scaler); err != nil {
 		_ = os.Remove(tmpPath)
 		return "", err
 	}
 
-	fmt.Printf("Download complete: %s\n", tmpPath)
+	logger.Info("Download complete: %s", tmpPath)
 	reporter.Report(progress.StageUpdate, 100, "Download complete")
 
 	return tmpPath, nil
@@ -1,7 +1,7 @@
 package updater
 
 import (
-	"HyLauncher/pkg/fileutil"
+	"HyLauncher/pkg/download"
 	"context"
 	"fmt"
 	"io"
@@ -11,7 +11,7 @@ import (
 )
 
 // Installs UpdateHelper
-func EnsureUpdateHelper(ctx context.Context) (string, error) {
+func EnsureUpdateHelper(ctx context.Context, channel download.Channel) (string, error) {
 	// Get path name for the executable that started the current process
 	exe, err := os.Executable()
 	if err != nil {
@@ -33,29 +33,22 @@ func EnsureUpdateHelper(ctx context.Context) (string, error) {
 		return helperPath, nil
 	}
 
-	fmt.Println("Update helper not found, downloading...")
+	logger.Info("Update helper not found, downloading...")
 
 	// Get info about latest update-helper as: url, hash
-	asset, err := GetHelperAsset(ctx)
+	asset, err := GetHelperAsset(ctx, channel)
 	if err != nil {
 		return "", fmt.Errorf("failed to get helper asset info: %w", err)
 	}
 
 	// Download latest update-helper, returned file path to temp file of helper
-	tmp, err := DownloadTemp(ctx, asset.URL, nil)
+	// Checksum is verified while downloading when provided
+	tmp, err := DownloadTemp(ctx, asset.URL, asset.Sha256, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to download helper: %w", err)
 	}
 	defer os.Remove(tmp)
 
-	// Verify checksum if provided
-	if asset.Sha256 != "" {
-		if err := fileutil.VerifySHA256(tmp, asset.Sha256); err != nil {
-			return "", fmt.Errorf("helper verification failed: %w", err)
-		}
-		fmt.Println("Helper verification successful")
-	}
-
 	// Move to final location
 	if err := MoveFile(tmp, helperPath); err != nil {
 		return "", fmt.Errorf("failed to install helper: %w", err)
@@ -68,7 +61,7 @@ func EnsureUpdateHelper(ctx context.Context) (string, error) {
 		}
 	}
 
-	fmt.Printf("Update helper installed: %s\n", helperPath)
+	logger.Info("Update helper installed: %s", helperPath)
 	return helperPath, nil
 }
 
@@ -2,124 +2,33 @@ package archive
 
 import (
 	"HyLauncher/pkg/fileutil"
-	"archive/tar"
+	"HyLauncher/pkg/logging"
 	"archive/zip"
-	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
-	"strings"
 )
 
-func ExtractZip(zipPath, dest string) error {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-
-	for _, f := range r.File {
-		fpath := filepath.Join(dest, f.Name)
-
-		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("illegal file path: %s", fpath)
-		}
-
-		if f.FileInfo().IsDir() {
-			if err := os.MkdirAll(fpath, f.Mode()); err != nil {
-				return err
-			}
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
-			return err
-		}
-
-		inFile, err := f.Open()
-		if err != nil {
-			return err
-		}
-
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			inFile.Close()
-			return err
-		}
-
-		_, err = io.Copy(outFile, inFile)
-
-		inFile.Close()
-		outFile.Close()
-
-		if err != nil {
-			return err
-		}
-	}
+var logger = logging.New("archive")
 
-	return nil
+func ExtractZip(zipPath, dest string) error {
+	return ExtractZipWithOptions(zipPath, dest, DefaultOptions())
 }
 
-func ExtractRar() {
-	// TODO IF NEEDED
+// ExtractZipWithOptions extracts a zip archive, failing with a *LimitError or
+// *PathError when the archive breaks opts or tries to escape dest
+func ExtractZipWithOptions(zipPath, dest string, opts Options) error {
+	return NewExtractor(opts, nil).ExtractZip(context.Background(), zipPath, dest)
 }
 
 func ExtractTarGz(tarGzPath, dest string) error {
-	file, err := os.Open(tarGzPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	gzr, err := gzip.NewReader(file)
-	if err != nil {
-		return err
-	}
-	defer gzr.Close()
-
-	tr := tar.NewReader(gzr)
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		target := filepath.Join(dest, header.Name)
-
-		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("illegal file path: %s", target)
-		}
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
-				return err
-			}
-
-		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
-			}
-
-			outFile, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
-
-			if _, err := io.Copy(outFile, tr); err != nil {
-				outFile.Close()
-				return err
-			}
+	return ExtractTarGzWithOptions(tarGzPath, dest, DefaultOptions())
+}
 
-			outFile.Close()
-		}
-	}
-	return nil
+// ExtractTarGzWithOptions extracts a gzipped tarball, failing with a *LimitError
+// or *PathError when the archive breaks opts or tries to escape dest
+func ExtractTarGzWithOptions(tarGzPath, dest string, opts Options) error {
+	return NewExtractor(opts, nil).ExtractTarGz(context.Background(), tarGzPath, dest)
 }
 
 func IsZipValid(path string) error {
@@ -0,0 +1,169 @@
+package archive
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mholt/archives"
+)
+
+// Formats Detect recognises on top of the ones Packer writes
+const (
+	FormatTar    Format = "tar"
+	FormatTarXz  Format = "tar.xz"
+	FormatTarBz2 Format = "tar.bz2"
+	Format7z     Format = "7z"
+	FormatRar    Format = "rar"
+)
+
+var magics = []struct {
+	format Format
+	offset int
+	magic  []byte
+}{
+	{FormatZip, 0, []byte("PK\x03\x04")},
+	{FormatZip, 0, []byte("PK\x05\x06")}, // Empty zip
+	{FormatTarGz, 0, []byte{0x1f, 0x8b}},
+	{FormatTarXz, 0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{FormatTarZst, 0, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{FormatTarBz2, 0, []byte("BZh")},
+	{Format7z, 0, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}},
+	{FormatRar, 0, []byte("Rar!\x1a\x07")},
+	{FormatTar, 257, []byte("ustar")},
+}
+
+// Detect identifies an archive from its magic bytes rather than its name.
+// Compressed streams are assumed to hold a tarball, the only thing we ship in them.
+func Detect(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	header = header[:n]
+
+	for _, m := range magics {
+		end := m.offset + len(m.magic)
+		if len(header) >= end && bytes.Equal(header[m.offset:end], m.magic) {
+			return m.format, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported archive format: %s", path)
+}
+
+// Extract detects the format of the archive at path and extracts it into dest
+func (e *Extractor) Extract(ctx context.Context, path, dest string) error {
+	format, err := Detect(path)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatZip:
+		return e.ExtractZip(ctx, path, dest)
+	case FormatTar:
+		return e.extractTarball(ctx, path, dest, func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(r), nil
+		})
+	case FormatTarGz:
+		return e.extractTarball(ctx, path, dest, func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		})
+	case FormatTarXz:
+		return e.extractTarball(ctx, path, dest, archives.Xz{}.OpenReader)
+	case FormatTarZst:
+		return e.extractTarball(ctx, path, dest, archives.Zstd{}.OpenReader)
+	case FormatTarBz2:
+		return e.extractTarball(ctx, path, dest, func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		})
+	case Format7z:
+		return e.extractWith(ctx, archives.SevenZip{}, path, dest)
+	case FormatRar:
+		return e.extractWith(ctx, archives.Rar{}, path, dest)
+	}
+
+	return fmt.Errorf("unsupported archive format: %s", format)
+}
+
+// extractWith extracts formats that need random access, 7z and rar, through
+// mholt/archives while keeping our own path, link and limit checks
+func (e *Extractor) extractWith(ctx context.Context, format archives.Extractor, path, dest string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Compressed progress is not exposed, so the whole file counts as read
+	// for the ratio limit and progress is tracked in entries only
+	x, err := e.start(ctx, dest, func() int64 { return info.Size() })
+	if err != nil {
+		return err
+	}
+	defer x.finish()
+
+	x.bytes = func() int64 { return x.limits.written }
+
+	return format.Extract(ctx, file, func(ctx context.Context, f archives.FileInfo) error {
+		target, err := resolvePath(dest, f.NameInArchive)
+		if err != nil {
+			return err
+		}
+
+		if err := x.next(f.NameInArchive); err != nil {
+			return err
+		}
+
+		// Formats without Unix modes report none, which would leave files unreadable
+		mode := withDefaultPerm(f.Mode())
+
+		switch {
+		case f.IsDir():
+			if err := x.writeDir(target, mode, f.ModTime()); err != nil {
+				return err
+			}
+
+		case mode&os.ModeSymlink != 0:
+			if err := writeSymlink(dest, target, f.LinkTarget); err != nil {
+				return err
+			}
+
+		default:
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = x.writeFile(f.NameInArchive, target, mode, f.ModTime(), rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		x.done()
+		return nil
+	})
+}
+
+// Extract detects the format of the archive at path and extracts it into dest
+func Extract(path, dest string, opts Options) error {
+	return NewExtractor(opts, nil).Extract(context.Background(), path, dest)
+}
@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withMagic pads magic at offset to a full header, the rest is noise no
+// format should match on
+func withMagic(offset int, magic string) []byte {
+	data := bytes.Repeat([]byte{0xaa}, 1024)
+	copy(data[offset:], magic)
+	return data
+}
+
+// plainTar builds an uncompressed tarball, which only has its magic at 257
+func plainTar(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "a", Mode: 0644, Size: 1, ModTime: testMtime, Format: tar.FormatUSTAR}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	zipData, err := os.ReadFile(writeZip(t, []testEntry{{name: "a", body: "a"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarGzData, err := os.ReadFile(writeTarGz(t, []testEntry{{name: "a", body: "a"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string // The extension is never trusted
+		data []byte
+		want Format // "" when detection must fail
+	}{
+		{"jre.zip", zipData, FormatZip},
+		{"empty.zip", withMagic(0, "PK\x05\x06"), FormatZip},
+		{"jre.tar.gz", tarGzData, FormatTarGz},
+		{"jre.tar.xz", withMagic(0, "\xfd7zXZ\x00"), FormatTarXz},
+		{"jre.tar.zst", withMagic(0, "\x28\xb5\x2f\xfd"), FormatTarZst},
+		{"jre.tar.bz2", withMagic(0, "BZh9"), FormatTarBz2},
+		{"mods.7z", withMagic(0, "7z\xbc\xaf\x27\x1c"), Format7z},
+		{"mods.rar", withMagic(0, "Rar!\x1a\x07\x01\x00"), FormatRar},
+		{"jre.tar", plainTar(t), FormatTar},
+
+		// Named for one format, holding another
+		{"jre.tar.gz", zipData, FormatZip},
+		{"jre.zip", tarGzData, FormatTarGz},
+		{"mods.zip", withMagic(0, "7z\xbc\xaf\x27\x1c"), Format7z},
+
+		// Too short for any magic
+		{"empty.zip", nil, ""},
+		{"short.zip", []byte("PK"), ""},
+		{"short.tar.xz", []byte("\xfd7zX"), ""},
+		{"short.tar", withMagic(257, "ust")[:260], ""},
+
+		{"jre.zip", withMagic(0, "not an archive"), ""},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.name)
+		if err := os.WriteFile(path, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Detect(path)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s (%d bytes): detected %s, want an error", tt.name, len(tt.data), got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s (%d bytes): got %s, %v, want %s", tt.name, len(tt.data), got, err, tt.want)
+		}
+	}
+}
+
+func TestDetectMissing(t *testing.T) {
+	if _, err := Detect(filepath.Join(t.TempDir(), "jre.zip")); !os.IsNotExist(err) {
+		t.Errorf("got %v, want a not exist error", err)
+	}
+}
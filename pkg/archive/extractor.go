@@ -0,0 +1,346 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// Progress describes how far an extraction has come. Bytes and TotalBytes share
+// a unit: uncompressed bytes for zip, compressed bytes read for tarballs, whose
+// uncompressed size is unknown until the end.
+type Progress struct {
+	Entries      int    `json:"entries"`
+	TotalEntries int    `json:"totalEntries"` // 0 when the format can not tell up front
+	Bytes        int64  `json:"bytes"`
+	TotalBytes   int64  `json:"totalBytes"`
+	Current      string `json:"current"`
+}
+
+// Percent returns the completion in the 0-100 range
+func (p Progress) Percent() float64 {
+	if p.TotalBytes > 0 {
+		return float64(p.Bytes) / float64(p.TotalBytes) * 100
+	}
+	if p.TotalEntries > 0 {
+		return float64(p.Entries) / float64(p.TotalEntries) * 100
+	}
+	return 0
+}
+
+type ProgressFunc func(Progress)
+
+// progressInterval throttles callbacks, a JRE has thousands of small files
+const progressInterval = 100 * time.Millisecond
+
+// Extractor extracts archives within Options limits, reports progress and stops
+// as soon as its context is cancelled
+type Extractor struct {
+	Options    Options
+	OnProgress ProgressFunc
+}
+
+func NewExtractor(opts Options, onProgress ProgressFunc) *Extractor {
+	return &Extractor{Options: opts, OnProgress: onProgress}
+}
+
+// extraction is the state of a single Extract call
+type extraction struct {
+	ctx        context.Context
+	dest       string
+	limits     *limiter
+	onProgress ProgressFunc
+	progress   Progress
+	bytes      func() int64
+	lastReport time.Time
+	dirs       dirTimes
+}
+
+func (e *Extractor) start(ctx context.Context, dest string, consumed func() int64) (*extraction, error) {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, err
+	}
+
+	return &extraction{
+		ctx:        ctx,
+		dest:       dest,
+		limits:     newLimiter(e.Options, consumed),
+		onProgress: e.OnProgress,
+		bytes:      consumed,
+		dirs:       dirTimes{},
+	}, nil
+}
+
+func (x *extraction) report(force bool) {
+	if x.onProgress == nil {
+		return
+	}
+
+	now := time.Now()
+	if !force && now.Sub(x.lastReport) < progressInterval {
+		return
+	}
+	x.lastReport = now
+
+	x.progress.Bytes = x.bytes()
+	x.onProgress(x.progress)
+}
+
+// next registers a new entry against the limits and cancellation
+func (x *extraction) next(name string) error {
+	if err := x.ctx.Err(); err != nil {
+		return err
+	}
+
+	x.progress.Current = name
+	return x.limits.entry(name)
+}
+
+func (x *extraction) done() {
+	x.progress.Entries++
+	x.report(false)
+}
+
+// copy streams src into dst, checking limits and cancellation before each chunk
+func (x *extraction) copy(name string, dst io.Writer, src io.Reader) error {
+	buf := make([]byte, 32*1024)
+	for {
+		if err := x.ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := src.Read(buf)
+		if n > 0 {
+			if limitErr := x.limits.add(name, int64(n)); limitErr != nil {
+				return limitErr
+			}
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			x.report(false)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeFile extracts a regular file, replacing whatever was at target before
+func (x *extraction) writeFile(name, target string, mode os.FileMode, mtime time.Time, src io.Reader) error {
+	if err := ensureParent(x.dest, target); err != nil {
+		return err
+	}
+
+	if err := removeExisting(target); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	err = x.copy(name, out, src)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return finishFile(target, mode, mtime)
+}
+
+func (x *extraction) writeDir(target string, mode os.FileMode, mtime time.Time) error {
+	if err := ensureParent(x.dest, target); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(target, mode.Perm()|0700); err != nil {
+		return err
+	}
+	x.dirs[target] = mtime
+	return nil
+}
+
+func (x *extraction) finish() {
+	x.dirs.apply()
+	x.report(true)
+}
+
+// ExtractZip extracts a zip archive into dest
+func (e *Extractor) ExtractZip(ctx context.Context, zipPath, dest string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var consumed int64
+	x, err := e.start(ctx, dest, func() int64 { return consumed })
+	if err != nil {
+		return err
+	}
+	defer x.finish()
+
+	// Zip progress is measured in uncompressed bytes, known from the central directory
+	x.bytes = func() int64 { return x.limits.written }
+	x.progress.TotalEntries = len(r.File)
+	for _, f := range r.File {
+		x.progress.TotalBytes += int64(f.UncompressedSize64)
+	}
+
+	for _, f := range r.File {
+		fpath, err := resolvePath(dest, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := x.next(f.Name); err != nil {
+			return err
+		}
+
+		consumed += int64(f.CompressedSize64)
+		if err := x.limits.declared(f.Name, int64(f.UncompressedSize64), int64(f.CompressedSize64)); err != nil {
+			return err
+		}
+
+		// Zips made without Unix attributes report no permissions
+		mode := withDefaultPerm(f.Mode())
+
+		switch {
+		case mode.IsDir():
+			if err := x.writeDir(fpath, mode, f.Modified); err != nil {
+				return err
+			}
+
+		case mode&os.ModeSymlink != 0:
+			// Zip stores the link target as the entry's content
+			linkname, err := readZipLink(f)
+			if err != nil {
+				return err
+			}
+			if err := writeSymlink(dest, fpath, linkname); err != nil {
+				return err
+			}
+
+		default:
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = x.writeFile(f.Name, fpath, mode, f.Modified, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		x.done()
+	}
+
+	return nil
+}
+
+func readZipLink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ExtractTarGz extracts a gzipped tarball into dest
+func (e *Extractor) ExtractTarGz(ctx context.Context, tarGzPath, dest string) error {
+	return e.extractTarball(ctx, tarGzPath, dest, func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	})
+}
+
+// extractTarball extracts a tar stream wrapped in the compression decompress opens
+func (e *Extractor) extractTarball(ctx context.Context, path, dest string, decompress func(io.Reader) (io.ReadCloser, error)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	counter := &countingReader{r: file}
+
+	dr, err := decompress(counter)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+
+	x, err := e.start(ctx, dest, func() int64 { return counter.n })
+	if err != nil {
+		return err
+	}
+	defer x.finish()
+
+	if info, err := file.Stat(); err == nil {
+		x.progress.TotalBytes = info.Size()
+	}
+
+	return x.extractTar(tar.NewReader(dr))
+}
+
+func (x *extraction) extractTar(tr *tar.Reader) error {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := resolvePath(x.dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := x.next(header.Name); err != nil {
+			return err
+		}
+
+		mode := header.FileInfo().Mode()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := x.writeDir(target, mode, header.ModTime); err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			if err := x.writeFile(header.Name, target, mode, header.ModTime, tr); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			if err := writeSymlink(x.dest, target, header.Linkname); err != nil {
+				return err
+			}
+
+		case tar.TypeLink:
+			if err := writeHardlink(x.dest, target, header.Linkname); err != nil {
+				return err
+			}
+		}
+
+		x.done()
+	}
+}
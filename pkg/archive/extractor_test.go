@@ -0,0 +1,544 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testEntry is one entry of a crafted archive
+type testEntry struct {
+	name string
+	typ  byte // tar.TypeReg, tar.TypeDir, tar.TypeSymlink or tar.TypeLink
+	body string
+	link string
+	mode int64
+}
+
+var testMtime = time.Unix(1700000000, 0)
+
+func (e testEntry) fileMode() int64 {
+	if e.mode != 0 {
+		return e.mode
+	}
+	if e.typ == tar.TypeDir {
+		return 0755
+	}
+	return 0644
+}
+
+// writeTarGz builds a gzipped tarball in memory and stores it outside any
+// destination used by the test
+func writeTarGz(t *testing.T, entries []testEntry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for _, e := range entries {
+		typ := e.typ
+		if typ == 0 {
+			typ = tar.TypeReg
+		}
+		header := &tar.Header{
+			Name:     e.name,
+			Typeflag: typ,
+			Linkname: e.link,
+			Mode:     e.fileMode(),
+			ModTime:  testMtime,
+		}
+		if typ == tar.TypeReg {
+			header.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if typ == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.tar.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeZip builds a zip in memory. Symlinks store their target as content,
+// zip has no hardlinks.
+func writeZip(t *testing.T, entries []testEntry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: testMtime}
+		body := e.body
+
+		switch e.typ {
+		case tar.TypeDir:
+			header.Name += "/"
+			header.SetMode(os.ModeDir | os.FileMode(e.fileMode()))
+		case tar.TypeSymlink:
+			header.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		case tar.TypeLink:
+			t.Fatalf("zip has no hardlinks: %s", e.name)
+		default:
+			header.SetMode(os.FileMode(e.fileMode()))
+		}
+
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sandbox returns a destination and an empty sibling directory an attack
+// would try to reach as ../outside
+func sandbox(t *testing.T) (dest, outside string) {
+	t.Helper()
+
+	root := t.TempDir()
+	outside = filepath.Join(root, "outside")
+	if err := os.Mkdir(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(root, "dest"), outside
+}
+
+// assertUntouched fails when anything but dest and outside/secret exists
+// next to dest, or when the secret changed
+func assertUntouched(t *testing.T, dest, outside string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(filepath.Dir(dest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "dest" && entry.Name() != "outside" {
+			t.Errorf("extraction created %s outside dest", entry.Name())
+		}
+	}
+
+	entries, err = os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "secret" {
+		t.Errorf("outside holds %d entries, want only secret", len(entries))
+	}
+
+	data, err := os.ReadFile(filepath.Join(outside, "secret"))
+	if err != nil || string(data) != "secret" {
+		t.Errorf("outside/secret was changed: %q, %v", data, err)
+	}
+}
+
+func extractTarGz(t *testing.T, entries []testEntry, dest string, opts Options) error {
+	return NewExtractor(opts, nil).ExtractTarGz(context.Background(), writeTarGz(t, entries), dest)
+}
+
+func extractZip(t *testing.T, entries []testEntry, dest string, opts Options) error {
+	return NewExtractor(opts, nil).ExtractZip(context.Background(), writeZip(t, entries), dest)
+}
+
+func TestExtractTarGzValid(t *testing.T) {
+	dest, outside := sandbox(t)
+
+	err := extractTarGz(t, []testEntry{
+		{name: "bin", typ: tar.TypeDir},
+		{name: "bin/java", body: "#!/bin/sh\n", mode: 0755},
+		{name: "lib/libjvm.so", body: "elf"},
+		{name: "lib/current", typ: tar.TypeSymlink, link: "libjvm.so"},
+		{name: "bin/java-link", typ: tar.TypeSymlink, link: "../lib/libjvm.so"},
+		{name: "bin/java2", typ: tar.TypeLink, link: "bin/java"},
+		{name: "lib/latest", typ: tar.TypeSymlink, link: "current"},      // Link to a link
+		{name: "bin/jdk", typ: tar.TypeSymlink, link: "../lib/next/jvm"}, // Target extracted later
+		{name: "lib/next/jvm", body: "next"},
+		{name: "bin/shared", body: "open", mode: 0777}, // Loses group and world write
+	}, dest, DefaultOptions())
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+
+	assertFile(t, filepath.Join(dest, "bin/java"), "#!/bin/sh\n", 0755)
+	assertFile(t, filepath.Join(dest, "lib/libjvm.so"), "elf", 0644)
+	assertSymlink(t, filepath.Join(dest, "lib/current"), "libjvm.so", "elf")
+	assertSymlink(t, filepath.Join(dest, "bin/java-link"), "../lib/libjvm.so", "elf")
+	assertHardlink(t, filepath.Join(dest, "bin/java"), filepath.Join(dest, "bin/java2"))
+	assertSymlink(t, filepath.Join(dest, "lib/latest"), "current", "elf")
+	assertSymlink(t, filepath.Join(dest, "bin/jdk"), "../lib/next/jvm", "next")
+	assertFile(t, filepath.Join(dest, "bin/shared"), "open", 0755)
+	assertMtime(t, filepath.Join(dest, "bin"))
+	assertUntouched(t, dest, outside)
+}
+
+func TestExtractZipValid(t *testing.T) {
+	dest, outside := sandbox(t)
+
+	err := extractZip(t, []testEntry{
+		{name: "bin", typ: tar.TypeDir},
+		{name: "bin/butler", body: "binary", mode: 0755},
+		{name: "lib/7z.so", body: "lib"},
+		{name: "bin/7z.so", typ: tar.TypeSymlink, link: "../lib/7z.so"},
+	}, dest, DefaultOptions())
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+
+	assertFile(t, filepath.Join(dest, "bin/butler"), "binary", 0755)
+	assertFile(t, filepath.Join(dest, "lib/7z.so"), "lib", 0644)
+	assertSymlink(t, filepath.Join(dest, "bin/7z.so"), "../lib/7z.so", "lib")
+	assertMtime(t, filepath.Join(dest, "bin"))
+	assertUntouched(t, dest, outside)
+}
+
+// TestExtractZipWithoutModes covers zips made on Unix without external
+// attributes, whose entries report no permissions at all
+func TestExtractZipWithoutModes(t *testing.T) {
+	dest, _ := sandbox(t)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range []struct{ name, body string }{{"bin/", ""}, {"bin/butler", "binary"}} {
+		// Made on Unix, but ExternalAttrs left empty
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, CreatorVersion: 3 << 8, Modified: testMtime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewExtractor(DefaultOptions(), nil).ExtractZip(context.Background(), path, dest); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	assertFile(t, filepath.Join(dest, "bin/butler"), "binary", 0644)
+
+	info, err := os.Stat(filepath.Join(dest, "bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("bin has mode %v, want 0755", info.Mode().Perm())
+	}
+}
+
+func TestExtractRejectsEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+		zip     bool // Also run against a zip, which has no hardlinks
+	}{
+		{
+			name:    "parent path",
+			entries: []testEntry{{name: "../outside/evil", body: "evil"}},
+			zip:     true,
+		},
+		{
+			name:    "nested parent path",
+			entries: []testEntry{{name: "lib/../../outside/evil", body: "evil"}},
+			zip:     true,
+		},
+		{
+			name:    "absolute symlink",
+			entries: []testEntry{{name: "passwd", typ: tar.TypeSymlink, link: "/etc/passwd"}},
+			zip:     true,
+		},
+		{
+			name: "write through symlink to outside",
+			entries: []testEntry{
+				{name: "escape", typ: tar.TypeSymlink, link: "../outside"},
+				{name: "escape/evil", body: "evil"},
+			},
+			zip: true,
+		},
+		{
+			name: "write through chained symlinks",
+			entries: []testEntry{
+				{name: "a", typ: tar.TypeDir},
+				{name: "a/up", typ: tar.TypeSymlink, link: ".."},
+				{name: "a/up/up", typ: tar.TypeSymlink, link: ".."},
+				{name: "a/up/up/outside/evil", body: "evil"},
+			},
+			zip: true,
+		},
+		{
+			name: "symlink climbing through another symlink",
+			entries: []testEntry{
+				{name: "a", typ: tar.TypeDir},
+				{name: "a/up", typ: tar.TypeSymlink, link: ".."},
+				{name: "a/evil", typ: tar.TypeSymlink, link: "up/.."},
+			},
+			zip: true,
+		},
+		{
+			name: "symlink climbing through a link created later",
+			entries: []testEntry{
+				{name: "x", typ: tar.TypeSymlink, link: "y/.."},
+				{name: "y", typ: tar.TypeSymlink, link: "."},
+			},
+			zip: true,
+		},
+		{
+			name: "symlink climbing through a file replaced later",
+			entries: []testEntry{
+				{name: "y", body: "file"},
+				{name: "x", typ: tar.TypeSymlink, link: "y/.."},
+				{name: "y", typ: tar.TypeSymlink, link: "."},
+			},
+			zip: true,
+		},
+		{
+			name: "hardlink to outside",
+			entries: []testEntry{
+				{name: "secret", typ: tar.TypeLink, link: "../outside/secret"},
+			},
+		},
+		{
+			name: "hardlink through symlink to outside",
+			entries: []testEntry{
+				{name: "dir", typ: tar.TypeSymlink, link: "."},
+				{name: "secret", typ: tar.TypeLink, link: "dir/../../outside/secret"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("tar/"+tt.name, func(t *testing.T) {
+			dest, outside := sandbox(t)
+			err := extractTarGz(t, tt.entries, dest, DefaultOptions())
+			assertPathError(t, err)
+			assertUntouched(t, dest, outside)
+		})
+
+		if !tt.zip {
+			continue
+		}
+		t.Run("zip/"+tt.name, func(t *testing.T) {
+			dest, outside := sandbox(t)
+			err := extractZip(t, tt.entries, dest, DefaultOptions())
+			assertPathError(t, err)
+			assertUntouched(t, dest, outside)
+		})
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	zeros := string(make([]byte, 4<<20))
+
+	tests := []struct {
+		name    string
+		opts    Options
+		entries []testEntry
+		want    error
+	}{
+		{
+			name: "too many files",
+			opts: Options{MaxFiles: 2},
+			entries: []testEntry{
+				{name: "a", body: "a"},
+				{name: "b", body: "b"},
+				{name: "c", body: "c"},
+			},
+			want: ErrTooManyFiles,
+		},
+		{
+			name:    "too large",
+			opts:    Options{MaxTotalSize: 1000},
+			entries: []testEntry{{name: "big", body: string(make([]byte, 4096))}},
+			want:    ErrTooLarge,
+		},
+		{
+			name: "too large across files",
+			opts: Options{MaxTotalSize: 1000},
+			entries: []testEntry{
+				{name: "a", body: string(make([]byte, 600))},
+				{name: "b", body: string(make([]byte, 600))},
+			},
+			want: ErrTooLarge,
+		},
+		{
+			name:    "compression ratio",
+			opts:    Options{MaxRatio: 100},
+			entries: []testEntry{{name: "bomb", body: zeros}},
+			want:    ErrRatioExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		for format, extract := range map[string]func(*testing.T, []testEntry, string, Options) error{
+			"tar": extractTarGz,
+			"zip": extractZip,
+		} {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				dest, outside := sandbox(t)
+				err := extract(t, tt.entries, dest, tt.opts)
+
+				var limitErr *LimitError
+				if !errors.As(err, &limitErr) {
+					t.Fatalf("got %v, want *LimitError", err)
+				}
+				if !errors.Is(err, tt.want) {
+					t.Errorf("got %v, want %v", limitErr.Err, tt.want)
+				}
+				assertUntouched(t, dest, outside)
+			})
+		}
+	}
+}
+
+func TestExtractWithinLimits(t *testing.T) {
+	dest, _ := sandbox(t)
+
+	err := extractTarGz(t, []testEntry{
+		{name: "a", body: "a"},
+		{name: "b", body: "b"},
+	}, dest, Options{MaxFiles: 2, MaxTotalSize: 2, MaxRatio: 1})
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+}
+
+func TestExtractCancelled(t *testing.T) {
+	dest, _ := sandbox(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	path := writeTarGz(t, []testEntry{{name: "a", body: "a"}})
+	err := NewExtractor(DefaultOptions(), nil).ExtractTarGz(ctx, path, dest)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
+
+func assertPathError(t *testing.T, err error) {
+	t.Helper()
+
+	var pathErr *PathError
+	if !errors.As(err, &pathErr) {
+		t.Fatalf("got %v, want *PathError", err)
+	}
+	if !errors.Is(err, ErrIllegalPath) {
+		t.Errorf("%v does not wrap ErrIllegalPath", err)
+	}
+}
+
+func assertFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("%s: content %q, want %q", path, data, content)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != perm {
+		t.Errorf("%s: mode %v, want %v", path, info.Mode().Perm(), perm)
+	}
+	if !info.ModTime().Equal(testMtime) {
+		t.Errorf("%s: mtime %v, want %v", path, info.ModTime(), testMtime)
+	}
+}
+
+func assertSymlink(t *testing.T, path, link, content string) {
+	t.Helper()
+
+	got, err := os.Readlink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != link {
+		t.Errorf("%s: points to %q, want %q", path, got, link)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("%s: resolves to %q, want %q", path, data, content)
+	}
+}
+
+func assertHardlink(t *testing.T, a, b string) {
+	t.Helper()
+
+	infoA, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(infoA, infoB) {
+		t.Errorf("%s is not a hardlink to %s", b, a)
+	}
+}
+
+func assertMtime(t *testing.T, path string) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(testMtime) {
+		t.Errorf("%s: mtime %v, want %v", path, info.ModTime(), testMtime)
+	}
+}
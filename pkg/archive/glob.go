@@ -0,0 +1,49 @@
+package archive
+
+import (
+	"path"
+	"strings"
+)
+
+// matchGlob matches a slash separated name against a pattern. Segments use
+// path.Match syntax, and a "**" segment matches any number of segments, so
+// "logs/**" covers everything below logs and "**/*.log" any .log file.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package archive
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"README", "README", true},
+		{"README", "docs/README", false},
+		{"*.log", "launcher.log", true},
+		{"*.log", "logs/launcher.log", false},
+		{"logs/*", "logs/launcher.log", true},
+		{"logs/*", "logs/old/launcher.log", false},
+		{"logs/**", "logs/old/launcher.log", true},
+		{"logs/**", "logs", true},
+		{"**/*.log", "launcher.log", true},
+		{"**/*.log", "logs/old/launcher.log", true},
+		{"**/*.log", "logs/launcher.log.gz", false},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+		{"[", "[", false}, // Malformed patterns match nothing
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
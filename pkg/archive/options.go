@@ -0,0 +1,125 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrIllegalPath   = errors.New("illegal path in archive")
+	ErrTooLarge      = errors.New("archive exceeds total size limit")
+	ErrTooManyFiles  = errors.New("archive exceeds file count limit")
+	ErrRatioExceeded = errors.New("archive exceeds compression ratio limit")
+)
+
+// Options bounds what an extraction may produce. A zero field disables that limit.
+type Options struct {
+	MaxTotalSize int64   // Total uncompressed bytes written
+	MaxFiles     int     // Number of entries, directories and links included
+	MaxRatio     float64 // Uncompressed bytes per compressed byte
+}
+
+// ratioFloor is how much must be written before MaxRatio applies, small
+// highly compressible files would trip it otherwise
+const ratioFloor = 1 << 20
+
+// DefaultOptions are sized well above a JRE or butler archive while still
+// stopping decompression bombs from third-party hosts
+func DefaultOptions() Options {
+	return Options{
+		MaxTotalSize: 4 << 30,
+		MaxFiles:     100_000,
+		MaxRatio:     100,
+	}
+}
+
+// LimitError is returned when an archive breaks one of the Options limits
+type LimitError struct {
+	Err    error  // ErrTooLarge, ErrTooManyFiles or ErrRatioExceeded
+	Entry  string // Entry being extracted when the limit was hit
+	Limit  float64
+	Actual float64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %s (limit %.0f, got %.0f)", e.Err, e.Entry, e.Limit, e.Actual)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// PathError is returned for entries that would be written outside the destination
+type PathError struct {
+	Entry  string
+	Reason string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", ErrIllegalPath, e.Entry, e.Reason)
+}
+
+func (e *PathError) Unwrap() error {
+	return ErrIllegalPath
+}
+
+// limiter tracks an extraction against its Options
+type limiter struct {
+	opts     Options
+	files    int
+	written  int64
+	consumed func() int64 // Compressed bytes read from the archive so far
+}
+
+func newLimiter(opts Options, consumed func() int64) *limiter {
+	return &limiter{opts: opts, consumed: consumed}
+}
+
+func (l *limiter) entry(name string) error {
+	l.files++
+	if l.opts.MaxFiles > 0 && l.files > l.opts.MaxFiles {
+		return &LimitError{Err: ErrTooManyFiles, Entry: name, Limit: float64(l.opts.MaxFiles), Actual: float64(l.files)}
+	}
+	return nil
+}
+
+// declared checks sizes an archive announces up front, before anything is written
+func (l *limiter) declared(name string, size, compressed int64) error {
+	if l.opts.MaxTotalSize > 0 && l.written+size > l.opts.MaxTotalSize {
+		return &LimitError{Err: ErrTooLarge, Entry: name, Limit: float64(l.opts.MaxTotalSize), Actual: float64(l.written + size)}
+	}
+	if l.opts.MaxRatio > 0 && size > ratioFloor && compressed > 0 {
+		if ratio := float64(size) / float64(compressed); ratio > l.opts.MaxRatio {
+			return &LimitError{Err: ErrRatioExceeded, Entry: name, Limit: l.opts.MaxRatio, Actual: ratio}
+		}
+	}
+	return nil
+}
+
+func (l *limiter) add(name string, n int64) error {
+	l.written += n
+	if l.opts.MaxTotalSize > 0 && l.written > l.opts.MaxTotalSize {
+		return &LimitError{Err: ErrTooLarge, Entry: name, Limit: float64(l.opts.MaxTotalSize), Actual: float64(l.written)}
+	}
+	if l.opts.MaxRatio > 0 && l.written > ratioFloor {
+		if consumed := l.consumed(); consumed > 0 {
+			if ratio := float64(l.written) / float64(consumed); ratio > l.opts.MaxRatio {
+				return &LimitError{Err: ErrRatioExceeded, Entry: name, Limit: l.opts.MaxRatio, Actual: ratio}
+			}
+		}
+	}
+	return nil
+}
+
+// countingReader counts compressed bytes pulled from the archive file
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,247 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// resolvePath joins an archive entry name onto dest and rejects names escaping it
+func resolvePath(dest, name string) (string, error) {
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", &PathError{Entry: name, Reason: "absolute path"}
+	}
+
+	target := filepath.Join(dest, name)
+	if !isWithin(dest, target) {
+		return "", &PathError{Entry: name, Reason: "outside destination"}
+	}
+	return target, nil
+}
+
+// isWithin reports whether path is root itself or lies below it. Comparing via
+// filepath.Rel avoids treating a sibling like "/jre-evil" as inside "/jre".
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || filepath.IsAbs(rel) {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// realDir resolves symlinks in dir, so checks see where writes actually land
+func realDir(dir string) (string, error) {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(real)
+}
+
+// ensureParent creates the parent directory of target. Symlinks extracted earlier
+// may sit on the way, so the deepest existing ancestor is resolved first and must
+// still be inside dest, otherwise MkdirAll would create directories outside it.
+func ensureParent(dest, target string) error {
+	parent := filepath.Dir(target)
+
+	existing := parent
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		next := filepath.Dir(existing)
+		if next == existing || !isWithin(dest, next) {
+			break
+		}
+		existing = next
+	}
+
+	realDest, err := realDir(dest)
+	if err != nil {
+		return err
+	}
+
+	realExisting, err := realDir(existing)
+	if err != nil {
+		return err
+	}
+
+	if !isWithin(realDest, realExisting) {
+		return &PathError{Entry: target, Reason: "leaves destination through a symlink"}
+	}
+
+	return os.MkdirAll(parent, 0755)
+}
+
+// removeExisting deletes whatever non-directory sits at target, so a file entry
+// never writes through a symlink that was extracted earlier
+func removeExisting(target string) error {
+	info, err := os.Lstat(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if info.IsDir() {
+		return nil
+	}
+	return os.Remove(target)
+}
+
+// writeSymlink creates a symlink at target pointing to linkname. Absolute targets
+// and relative ones leaving dest are rejected.
+func writeSymlink(dest, target, linkname string) error {
+	if linkname == "" || filepath.IsAbs(linkname) || filepath.VolumeName(linkname) != "" {
+		return &PathError{Entry: target, Reason: "symlink to absolute or empty target " + linkname}
+	}
+
+	if err := ensureParent(dest, target); err != nil {
+		return err
+	}
+
+	realDest, err := realDir(dest)
+	if err != nil {
+		return err
+	}
+
+	realParent, err := realDir(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+
+	if !resolvesWithin(realDest, realParent, linkname) {
+		return &PathError{Entry: target, Reason: "symlink to " + linkname + " points outside destination"}
+	}
+
+	if err := removeExisting(target); err != nil {
+		return err
+	}
+
+	return os.Symlink(linkname, target)
+}
+
+// maxLinkHops bounds how many symlinks resolvesWithin follows, like ELOOP
+const maxLinkHops = 40
+
+// resolvesWithin follows linkname from dir one component at a time, the way
+// the OS will, and reports whether every step stays inside root. Joining it
+// lexically is not enough: with "up" linking to "..", "up/.." cleans to dir
+// but resolves to the parent of up's target. Links are read rather than
+// evaluated, their own targets may not be extracted yet.
+//
+// A ".." is only followed out of a directory that already exists. A missing
+// component or a file could be replaced by a symlink later in the archive,
+// "y/.." then lands wherever y's parent is once y links to ".".
+func resolvesWithin(root, dir, linkname string) bool {
+	current := dir
+	parts := strings.Split(filepath.ToSlash(linkname), "/")
+	hops := 0
+
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if info, err := os.Lstat(current); err != nil || !info.IsDir() {
+				return false
+			}
+			current = filepath.Dir(current)
+		default:
+			next := filepath.Join(current, part)
+			info, err := os.Lstat(next)
+			if err != nil || info.Mode()&os.ModeSymlink == 0 {
+				current = next
+				break
+			}
+
+			hops++
+			link, err := os.Readlink(next)
+			if err != nil || hops > maxLinkHops || filepath.IsAbs(link) || filepath.VolumeName(link) != "" {
+				return false
+			}
+			// Continue with the link's target in place of this component
+			parts = append(strings.Split(filepath.ToSlash(link), "/"), parts...)
+		}
+
+		if !isWithin(root, current) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeHardlink links target to an entry extracted earlier. linkname is relative
+// to the archive root, as stored in tar headers.
+func writeHardlink(dest, target, linkname string) error {
+	source, err := resolvePath(dest, linkname)
+	if err != nil {
+		return &PathError{Entry: target, Reason: "hardlink to " + linkname + " points outside destination"}
+	}
+
+	realDest, err := realDir(dest)
+	if err != nil {
+		return err
+	}
+
+	realSource, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return fmt.Errorf("hardlink %s -> %s: %w", target, linkname, err)
+	}
+
+	if !isWithin(realDest, realSource) {
+		return &PathError{Entry: target, Reason: "hardlink to " + linkname + " points outside destination"}
+	}
+
+	if err := ensureParent(dest, target); err != nil {
+		return err
+	}
+
+	if err := removeExisting(target); err != nil {
+		return err
+	}
+
+	return os.Link(realSource, target)
+}
+
+// dirTimes collects directory mtimes, applied once extraction is done because
+// creating files inside a directory bumps its mtime again
+type dirTimes map[string]time.Time
+
+func (d dirTimes) apply() {
+	for dir, mtime := range d {
+		_ = os.Chtimes(dir, mtime, mtime)
+	}
+}
+
+// withDefaultPerm gives entries stored without Unix permissions, as 7z, rar
+// and some zips are, the usual 0755 for directories and 0644 for files
+func withDefaultPerm(mode os.FileMode) os.FileMode {
+	if mode.Perm() != 0 {
+		return mode
+	}
+	if mode.IsDir() {
+		return mode | 0755
+	}
+	return mode | 0644
+}
+
+// finishFile applies permissions and mtime to an extracted regular file. The mode
+// is set explicitly because OpenFile only uses it when creating the file, so the
+// group and world write bits a umask would drop are dropped here.
+func finishFile(target string, mode os.FileMode, mtime time.Time) error {
+	if err := os.Chmod(target, mode.Perm()&^0o022); err != nil {
+		return err
+	}
+
+	if !mtime.IsZero() {
+		return os.Chtimes(target, mtime, mtime)
+	}
+	return nil
+}
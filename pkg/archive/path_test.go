@@ -0,0 +1,157 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "dest")
+
+	tests := []struct {
+		name string
+		want string // "" when the name must be rejected
+	}{
+		{name: "bin/java", want: filepath.Join(dest, "bin", "java")},
+		{name: "./bin/../lib/x", want: filepath.Join(dest, "lib", "x")},
+		{name: ".", want: dest},
+		{name: "..", want: ""},
+		{name: "../dest-evil/x", want: ""},
+		{name: "lib/../../x", want: ""},
+		{name: "/etc/passwd", want: ""},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, struct{ name, want string }{name: `C:\Windows\evil`, want: ""})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePath(dest, tt.name)
+			if tt.want == "" {
+				assertPathError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("rejected: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	root := filepath.Join("base", "jre")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{root, true},
+		{filepath.Join(root, "bin"), true},
+		{filepath.Join(root, "..jre", "bin"), true},
+		{filepath.Join("base", "jre-evil"), false},
+		{"base", false},
+		{filepath.Join(root, "..", "other"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isWithin(root, tt.path); got != tt.want {
+			t.Errorf("isWithin(%s, %s) = %v, want %v", root, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSymlink(t *testing.T) {
+	dest, outside := sandbox(t)
+	if err := os.MkdirAll(filepath.Join(dest, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeSymlink(dest, filepath.Join(dest, "lib", "self"), "."); err != nil {
+		t.Errorf("link to its own directory: %v", err)
+	}
+	if err := writeSymlink(dest, filepath.Join(dest, "lib", "up"), ".."); err != nil {
+		t.Errorf("link to dest: %v", err)
+	}
+
+	for _, link := range []string{"", "/etc", "../..", "../../outside", "up/.."} {
+		err := writeSymlink(dest, filepath.Join(dest, "lib", "evil"), link)
+		assertPathError(t, err)
+	}
+
+	// Through lib/up the parent is dest itself, one more level leaves it
+	err := writeSymlink(dest, filepath.Join(dest, "lib", "up", "evil"), "..")
+	assertPathError(t, err)
+
+	if _, err := os.Lstat(filepath.Join(dest, "lib", "evil")); !os.IsNotExist(err) {
+		t.Errorf("rejected symlink exists: %v", err)
+	}
+	assertUntouched(t, dest, outside)
+}
+
+func TestWriteHardlink(t *testing.T) {
+	dest, outside := sandbox(t)
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "a"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeHardlink(dest, filepath.Join(dest, "sub", "b"), "a"); err != nil {
+		t.Fatalf("link inside dest: %v", err)
+	}
+	assertHardlink(t, filepath.Join(dest, "a"), filepath.Join(dest, "sub", "b"))
+
+	// A symlink inside dest pointing out must not be usable as a hardlink source
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(dest, "planted")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, link := range []string{"../outside/secret", "/etc/passwd", "planted"} {
+		err := writeHardlink(dest, filepath.Join(dest, "evil"), link)
+		assertPathError(t, err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(dest, "evil")); !os.IsNotExist(err) {
+		t.Errorf("rejected hardlink exists: %v", err)
+	}
+	assertUntouched(t, dest, outside)
+}
+
+func TestEnsureParentThroughSymlink(t *testing.T) {
+	dest, outside := sandbox(t)
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dest, "planted")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ensureParent(dest, filepath.Join(dest, "planted", "new", "evil"))
+	assertPathError(t, err)
+	assertUntouched(t, dest, outside)
+}
+
+func TestRemoveExistingKeepsSymlinkTarget(t *testing.T) {
+	dest, outside := sandbox(t)
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dest, "secret")
+	if err := os.Symlink(filepath.Join(outside, "secret"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := removeExisting(link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Errorf("symlink still exists: %v", err)
+	}
+	assertUntouched(t, dest, outside)
+}
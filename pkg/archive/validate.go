@@ -0,0 +1,212 @@
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// VerifyOptions controls a full zip integrity check
+type VerifyOptions struct {
+	Workers    int // 0 uses one worker per CPU
+	OnProgress ProgressFunc
+	CacheFile  string // Records a passed check by zip size and mtime, "" disables caching
+	Force      bool   // Verify even when CacheFile says the zip is unchanged
+}
+
+// verifyRecord is what CacheFile stores after a successful check
+type verifyRecord struct {
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"modTime"`
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// VerifyZip decompresses every entry of a zip so archive/zip checks its CRC32,
+// unlike IsZipValid which only reads the first byte. Entries are spread over
+// opts.Workers goroutines, the first failure cancels the rest.
+func VerifyZip(ctx context.Context, path string, opts VerifyOptions) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("can not find zip: %w", err)
+	}
+
+	if !opts.Force && opts.CacheFile != "" && isVerified(info, opts.CacheFile) {
+		return nil
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("invalid zip: %w", err)
+	}
+	defer r.Close()
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var total int64
+	for _, f := range r.File {
+		total += int64(f.UncompressedSize64)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		verified atomic.Int64
+		entries  atomic.Int64
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	files := make(chan *zip.File)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range files {
+				if err := verifyEntry(ctx, f, &verified); err != nil {
+					fail(err)
+					return
+				}
+				entries.Add(1)
+			}
+		}()
+	}
+
+	stopProgress := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		if opts.OnProgress == nil {
+			return
+		}
+
+		report := func() {
+			opts.OnProgress(Progress{
+				Entries:      int(entries.Load()),
+				TotalEntries: len(r.File),
+				Bytes:        verified.Load(),
+				TotalBytes:   total,
+			})
+		}
+
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				report()
+			case <-stopProgress:
+				report()
+				return
+			}
+		}
+	}()
+
+feed:
+	for _, f := range r.File {
+		select {
+		case files <- f:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(files)
+	wg.Wait()
+
+	close(stopProgress)
+	<-progressDone
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if opts.CacheFile != "" {
+		if err := saveVerified(info, opts.CacheFile); err != nil {
+			logger.Warn("failed to cache zip verification: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func verifyEntry(ctx context.Context, f *zip.File, verified *atomic.Int64) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("cannot open file %s in zip: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	buf := make([]byte, 64*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := rc.Read(buf)
+		verified.Add(int64(n))
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// zip.ErrChecksum surfaces here once the entry is fully read
+			return fmt.Errorf("corrupted file %s in zip: %w", f.Name, err)
+		}
+	}
+}
+
+// IsZipVerified reports whether cacheFile records a passed VerifyZip for the
+// zip as it is on disk now
+func IsZipVerified(path, cacheFile string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return isVerified(info, cacheFile)
+}
+
+func isVerified(info os.FileInfo, cacheFile string) bool {
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return false
+	}
+
+	var record verifyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return false
+	}
+
+	return record.Size == info.Size() && record.ModTime.Equal(info.ModTime())
+}
+
+func saveVerified(info os.FileInfo, cacheFile string) error {
+	data, err := json.MarshalIndent(verifyRecord{
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+		VerifiedAt: time.Now(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cacheFile, data, 0644)
+}
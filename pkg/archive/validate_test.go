@@ -0,0 +1,201 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var verifyBody = bytes.Repeat([]byte("hytale assets "), 1024)
+
+// writeStoredZip writes a zip of uncompressed entries, so their bodies can be
+// found and damaged in the file
+func writeStoredZip(t *testing.T) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"Common/a.bin", "Common/b.bin", "Server/c.bin"} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: testMtime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(verifyBody); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "Assets.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, testMtime, testMtime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// corrupt flips a byte inside the last entry body and keeps the size and
+// mtime, as bit rot would
+func corrupt(t *testing.T, path string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.LastIndex(data, verifyBody)
+	if i < 0 {
+		t.Fatal("entry body not found")
+	}
+	data[i+len(verifyBody)/2] ^= 0xff
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, testMtime, testMtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyZipValid(t *testing.T) {
+	path := writeStoredZip(t)
+
+	var last Progress
+	err := VerifyZip(context.Background(), path, VerifyOptions{
+		Workers:    2,
+		OnProgress: func(p Progress) { last = p },
+	})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if last.Entries != 3 || last.TotalEntries != 3 || last.Bytes != last.TotalBytes {
+		t.Errorf("final progress %+v, want every entry and byte", last)
+	}
+}
+
+func TestVerifyZipCRCMismatch(t *testing.T) {
+	path := writeStoredZip(t)
+	corrupt(t, path)
+
+	// Opening the entry and reading its first byte still works
+	if err := IsZipValid(path); err != nil {
+		t.Fatalf("IsZipValid rejects the zip, the test no longer needs a full check: %v", err)
+	}
+
+	err := VerifyZip(context.Background(), path, VerifyOptions{})
+	if !errors.Is(err, zip.ErrChecksum) {
+		t.Errorf("got %v, want zip.ErrChecksum", err)
+	}
+}
+
+func TestVerifyZipTruncated(t *testing.T) {
+	path := writeStoredZip(t)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyZip(context.Background(), path, VerifyOptions{}); err == nil {
+		t.Error("truncated zip passed")
+	}
+}
+
+func TestVerifyZipMissing(t *testing.T) {
+	if err := VerifyZip(context.Background(), filepath.Join(t.TempDir(), "Assets.zip"), VerifyOptions{}); err == nil {
+		t.Error("missing zip passed")
+	}
+}
+
+func TestVerifyZipCancelled(t *testing.T) {
+	path := writeStoredZip(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := VerifyZip(ctx, path, VerifyOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestVerifyZipCache(t *testing.T) {
+	path := writeStoredZip(t)
+	cacheFile := filepath.Join(t.TempDir(), "verified.json")
+	opts := VerifyOptions{CacheFile: cacheFile}
+
+	if IsZipVerified(path, cacheFile) {
+		t.Fatal("verified before any check")
+	}
+	if err := VerifyZip(context.Background(), path, opts); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !IsZipVerified(path, cacheFile) {
+		t.Fatal("passed check not recorded")
+	}
+
+	// Same size and mtime, the cache answers without reading the damage
+	corrupt(t, path)
+	if err := VerifyZip(context.Background(), path, opts); err != nil {
+		t.Errorf("cache hit: got %v, want the recorded pass", err)
+	}
+
+	force := opts
+	force.Force = true
+	if err := VerifyZip(context.Background(), path, force); !errors.Is(err, zip.ErrChecksum) {
+		t.Errorf("forced: got %v, want zip.ErrChecksum", err)
+	}
+
+	// A new mtime invalidates the record
+	later := testMtime.Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if IsZipVerified(path, cacheFile) {
+		t.Error("record still matches after an mtime change")
+	}
+	if err := VerifyZip(context.Background(), path, opts); !errors.Is(err, zip.ErrChecksum) {
+		t.Errorf("after mtime change: got %v, want zip.ErrChecksum", err)
+	}
+	if IsZipVerified(path, cacheFile) {
+		t.Error("failed check recorded as passed")
+	}
+}
+
+func TestVerifyZipCacheSizeChange(t *testing.T) {
+	path := writeStoredZip(t)
+	cacheFile := filepath.Join(t.TempDir(), "verified.json")
+
+	if err := VerifyZip(context.Background(), path, VerifyOptions{CacheFile: cacheFile}); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// Appended bytes keep the zip readable but change its size
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("trailing")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := os.Chtimes(path, testMtime, testMtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if IsZipVerified(path, cacheFile) {
+		t.Error("record still matches after a size change")
+	}
+}
@@ -0,0 +1,403 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mholt/archives"
+)
+
+// Format is an archive format Packer can write
+type Format string
+
+const (
+	FormatZip    Format = "zip"
+	FormatTarGz  Format = "tar.gz"
+	FormatTarZst Format = "tar.zst"
+)
+
+// PackOptions controls what ends up in an archive
+type PackOptions struct {
+	Include    []string // Globs relative to the source dir, empty includes everything
+	Exclude    []string // Globs removed again after Include, a matching directory is skipped whole
+	OnProgress ProgressFunc
+}
+
+// Packer streams files into an archive. Directories are walked in lexical
+// order, so packing the same tree twice yields entries in the same order.
+type Packer struct {
+	format Format
+	opts   PackOptions
+
+	zw   *zip.Writer
+	tw   *tar.Writer
+	comp io.WriteCloser // Compressor under tw
+
+	progress   Progress
+	lastReport time.Time
+}
+
+func NewPacker(w io.Writer, format Format, opts PackOptions) (*Packer, error) {
+	p := &Packer{format: format, opts: opts}
+
+	switch format {
+	case FormatZip:
+		p.zw = zip.NewWriter(w)
+	case FormatTarGz:
+		p.comp = gzip.NewWriter(w)
+		p.tw = tar.NewWriter(p.comp)
+	case FormatTarZst:
+		comp, err := archives.Zstd{}.OpenWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		p.comp = comp
+		p.tw = tar.NewWriter(p.comp)
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	return p, nil
+}
+
+// packEntry is a file or directory selected for packing
+type packEntry struct {
+	path string // On disk
+	name string // In the archive
+	info fs.FileInfo
+}
+
+// AddDir adds the contents of srcDir below prefix in the archive, filtered by
+// the Include and Exclude globs. Directories only get an entry when something
+// inside them is packed.
+func (p *Packer) AddDir(ctx context.Context, srcDir, prefix string) error {
+	var entries []packEntry
+	used := make(map[string]bool)
+
+	err := filepath.WalkDir(srcDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, filePath)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchAny(p.opts.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := packEntry{path: filePath, name: path.Join(prefix, rel), info: info}
+		if d.IsDir() {
+			entries = append(entries, entry)
+			return nil
+		}
+
+		if len(p.opts.Include) > 0 && !matchAny(p.opts.Include, rel) {
+			return nil
+		}
+
+		entries = append(entries, entry)
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			used[path.Join(prefix, dir)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	selected := entries[:0]
+	for _, entry := range entries {
+		if entry.info.IsDir() && !used[entry.name] {
+			continue
+		}
+		selected = append(selected, entry)
+		p.progress.TotalEntries++
+		if entry.info.Mode().IsRegular() {
+			p.progress.TotalBytes += entry.info.Size()
+		}
+	}
+
+	for _, entry := range selected {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.addEntry(ctx, entry); err != nil {
+			return fmt.Errorf("pack %s: %w", entry.path, err)
+		}
+	}
+
+	p.report(true)
+	return nil
+}
+
+// AddFile adds a single file from disk as name
+func (p *Packer) AddFile(ctx context.Context, srcPath, name string) error {
+	info, err := os.Lstat(srcPath)
+	if err != nil {
+		return err
+	}
+
+	p.progress.TotalEntries++
+	if info.Mode().IsRegular() {
+		p.progress.TotalBytes += info.Size()
+	}
+
+	return p.addEntry(ctx, packEntry{path: srcPath, name: name, info: info})
+}
+
+// AddBytes adds generated content, such as a report, as name
+func (p *Packer) AddBytes(name string, data []byte) error {
+	modTime := time.Now()
+
+	if p.zw != nil {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+		hdr.SetMode(0644)
+		w, err := p.zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	}
+	if err := p.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := p.tw.Write(data)
+	return err
+}
+
+func (p *Packer) addEntry(ctx context.Context, entry packEntry) error {
+	p.progress.Current = entry.name
+
+	var err error
+	if p.zw != nil {
+		err = p.addZipEntry(ctx, entry)
+	} else {
+		err = p.addTarEntry(ctx, entry)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.progress.Entries++
+	p.report(false)
+	return nil
+}
+
+func (p *Packer) addZipEntry(ctx context.Context, entry packEntry) error {
+	hdr, err := zip.FileInfoHeader(entry.info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = entry.name
+
+	mode := entry.info.Mode()
+	switch {
+	case mode.IsDir():
+		hdr.Name += "/"
+		_, err := p.zw.CreateHeader(hdr)
+		return err
+
+	case mode&os.ModeSymlink != 0:
+		// Zip stores the link target as the entry's content
+		target, err := os.Readlink(entry.path)
+		if err != nil {
+			return err
+		}
+		w, err := p.zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, filepath.ToSlash(target))
+		return err
+
+	case mode.IsRegular():
+		hdr.Method = zip.Deflate
+		w, err := p.zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		return p.copyFile(ctx, w, entry.path)
+	}
+
+	// Sockets, devices and pipes have no place in an archive
+	return nil
+}
+
+func (p *Packer) addTarEntry(ctx context.Context, entry packEntry) error {
+	mode := entry.info.Mode()
+
+	var link string
+	if mode&os.ModeSymlink != 0 {
+		target, err := os.Readlink(entry.path)
+		if err != nil {
+			return err
+		}
+		link = filepath.ToSlash(target)
+	} else if !mode.IsDir() && !mode.IsRegular() {
+		return nil
+	}
+
+	hdr, err := tar.FileInfoHeader(entry.info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = entry.name
+	if mode.IsDir() {
+		hdr.Name += "/"
+	}
+
+	// Owner names differ between machines and only add noise
+	hdr.Uname, hdr.Gname = "", ""
+
+	if err := p.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if mode.IsRegular() {
+		return p.copyFile(ctx, p.tw, entry.path)
+	}
+	return nil
+}
+
+func (p *Packer) copyFile(ctx context.Context, w io.Writer, srcPath string) error {
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 64*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := f.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			p.progress.Bytes += int64(n)
+			p.report(false)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Packer) report(force bool) {
+	if p.opts.OnProgress == nil {
+		return
+	}
+
+	now := time.Now()
+	if !force && now.Sub(p.lastReport) < progressInterval {
+		return
+	}
+	p.lastReport = now
+	p.opts.OnProgress(p.progress)
+}
+
+// Close finishes the archive. It does not close the underlying writer.
+func (p *Packer) Close() error {
+	if p.zw != nil {
+		return p.zw.Close()
+	}
+
+	if err := p.tw.Close(); err != nil {
+		p.comp.Close()
+		return err
+	}
+	return p.comp.Close()
+}
+
+// FormatFromName picks a Format from an archive file name
+func FormatFromName(name string) (Format, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return FormatTarGz, nil
+	case strings.HasSuffix(lower, ".tar.zst"):
+		return FormatTarZst, nil
+	}
+	return "", fmt.Errorf("unsupported archive format: %s", name)
+}
+
+// Create packs srcDir into destPath. The archive is written to a temporary file
+// next to destPath first, so a failed or cancelled run leaves nothing behind.
+func Create(ctx context.Context, format Format, srcDir, destPath string, opts PackOptions) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := destPath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	err = func() error {
+		packer, err := NewPacker(out, format, opts)
+		if err != nil {
+			return err
+		}
+		if err := packer.AddDir(ctx, srcDir, ""); err != nil {
+			packer.Close()
+			return err
+		}
+		if err := packer.Close(); err != nil {
+			return err
+		}
+		return out.Sync()
+	}()
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, destPath)
+}
@@ -0,0 +1,208 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// packTree builds a small source tree with fixed mtimes, so archives of it
+// can be compared byte for byte
+func packTree(t *testing.T) string {
+	t.Helper()
+
+	src := filepath.Join(t.TempDir(), "src")
+	files := []struct {
+		name, body string
+		mode       os.FileMode
+	}{
+		{"README", "readme", 0644},
+		{"bin/run", "#!/bin/sh\n", 0755},
+		{"lib/a.so", "a", 0644},
+		{"logs/x.log", "x", 0644},
+		{"logs/old/y.log", "y", 0644},
+	}
+	for _, f := range files {
+		path := filepath.Join(src, f.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f.body), f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, f.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a.so", filepath.Join(src, "lib/link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Directories last, writing files into them bumped their mtimes
+	for _, name := range []string{"README", "bin/run", "lib/a.so", "logs/x.log", "logs/old/y.log", "bin", "lib", "logs/old", "logs"} {
+		if err := os.Chtimes(filepath.Join(src, name), testMtime, testMtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src
+}
+
+var packFormats = []struct {
+	format Format
+	ext    string
+}{
+	{FormatZip, ".zip"},
+	{FormatTarGz, ".tar.gz"},
+	{FormatTarZst, ".tar.zst"},
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	src := packTree(t)
+
+	for _, tt := range packFormats {
+		t.Run(string(tt.format), func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "out"+tt.ext)
+			if err := Create(context.Background(), tt.format, src, archivePath, PackOptions{}); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			detected, err := Detect(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if detected != tt.format {
+				t.Errorf("detected %s, want %s", detected, tt.format)
+			}
+
+			dest := filepath.Join(t.TempDir(), "dest")
+			if err := NewExtractor(DefaultOptions(), nil).Extract(context.Background(), archivePath, dest); err != nil {
+				t.Fatalf("extract: %v", err)
+			}
+
+			assertFile(t, filepath.Join(dest, "README"), "readme", 0644)
+			assertFile(t, filepath.Join(dest, "bin/run"), "#!/bin/sh\n", 0755)
+			assertFile(t, filepath.Join(dest, "logs/old/y.log"), "y", 0644)
+			assertSymlink(t, filepath.Join(dest, "lib/link"), "a.so", "a")
+			assertMtime(t, filepath.Join(dest, "logs"))
+		})
+	}
+}
+
+func TestPackFilters(t *testing.T) {
+	src := packTree(t)
+	archivePath := filepath.Join(t.TempDir(), "out.zip")
+
+	err := Create(context.Background(), FormatZip, src, archivePath, PackOptions{
+		Include: []string{"README", "**/*.log", "lib/*"},
+		Exclude: []string{"logs/old", "lib/link"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+
+	// bin has nothing included, so it gets no directory entry either
+	want := []string{"README", "lib/", "lib/a.so", "logs/", "logs/x.log"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("packed %v, want %v", names, want)
+	}
+}
+
+func TestPackDeterministic(t *testing.T) {
+	src := packTree(t)
+
+	for _, tt := range packFormats {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var runs [2][]byte
+			for i := range runs {
+				archivePath := filepath.Join(t.TempDir(), "out"+tt.ext)
+				if err := Create(context.Background(), tt.format, src, archivePath, PackOptions{}); err != nil {
+					t.Fatalf("create: %v", err)
+				}
+
+				data, err := os.ReadFile(archivePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				runs[i] = data
+			}
+
+			if !bytes.Equal(runs[0], runs[1]) {
+				t.Errorf("two runs differ: %d and %d bytes", len(runs[0]), len(runs[1]))
+			}
+		})
+	}
+}
+
+func TestPackCancelled(t *testing.T) {
+	src := t.TempDir()
+	// Several copy chunks, so the cancel lands in the middle of the file
+	if err := os.WriteFile(filepath.Join(src, "big"), make([]byte, 1<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	archivePath := filepath.Join(t.TempDir(), "out.tar.gz")
+	err := Create(ctx, FormatTarGz, src, archivePath, PackOptions{
+		OnProgress: func(p Progress) {
+			if p.Bytes > 0 {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+
+	for _, path := range []string{archivePath, archivePath + ".tmp"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s left behind: %v", path, err)
+		}
+	}
+}
+
+func TestFormatFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format // "" when the name must be rejected
+	}{
+		{"bundle.zip", FormatZip},
+		{"BUNDLE.ZIP", FormatZip},
+		{"logs.tar.gz", FormatTarGz},
+		{"logs.tgz", FormatTarGz},
+		{"logs.tar.zst", FormatTarZst},
+		{"logs.tar", ""},
+		{"logs.gz", ""},
+		{"zip", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := FormatFromName(tt.name)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("FormatFromName(%q) = %s, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("FormatFromName(%q) = %s, %v, want %s", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -2,138 +2,343 @@ package download
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"HyLauncher/internal/endpoints"
 	"HyLauncher/internal/progress"
+	"HyLauncher/pkg/network"
 )
 
+// githubTimeout bounds an API call, the asset download has its own limit
+const githubTimeout = 30 * time.Second
+
+// Channel selects which releases the launcher updates to
+type Channel string
+
 const (
-	defaultRepoOwner = "ArchDevs"
-	defaultRepoName  = "HyLauncher"
+	ChannelStable     Channel = "stable"
+	ChannelPrerelease Channel = "prerelease" // Newest release, prereleases included
 )
 
+// ParseChannel accepts "stable", "prerelease" or an empty string for stable
+func ParseChannel(s string) (Channel, error) {
+	switch Channel(strings.ToLower(strings.TrimSpace(s))) {
+	case ChannelStable, "":
+		return ChannelStable, nil
+	case ChannelPrerelease:
+		return ChannelPrerelease, nil
+	default:
+		return "", fmt.Errorf("unknown update channel %q, use stable or prerelease", s)
+	}
+}
+
 type GitHubReleaseAsset struct {
 	Name               string `json:"name"`
 	BrowserDownloadURL string `json:"browser_download_url"`
 	Size               int64  `json:"size"`
+	Digest             string `json:"digest"` // "sha256:<hex>", missing on older releases
+}
+
+// expected returns what the downloaded asset has to match
+func (a GitHubReleaseAsset) expected() Expected {
+	exp := Expected{Size: a.Size}
+	if sum, ok := strings.CutPrefix(a.Digest, "sha256:"); ok {
+		exp.SHA256 = sum
+	}
+	return exp
 }
 
 type GitHubRelease struct {
-	TagName string               `json:"tag_name"`
-	Name    string               `json:"name"`
-	Assets  []GitHubReleaseAsset `json:"assets"`
+	TagName     string               `json:"tag_name"`
+	Name        string               `json:"name"`
+	Draft       bool                 `json:"draft"`
+	Prerelease  bool                 `json:"prerelease"`
+	PublishedAt time.Time            `json:"published_at"`
+	Assets      []GitHubReleaseAsset `json:"assets"`
 }
 
-// DownloadLatestReleaseAsset downloads an asset from the latest GitHub release
-// If reporter and scaler are nil, downloads silently without progress updates
-func DownloadLatestReleaseAsset(
-	ctx context.Context,
-	assetName string,
-	destPath string,
-	stage progress.Stage,
-	reporter *progress.Reporter,
-	scaler *progress.Scaler,
-) error {
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", defaultRepoOwner, defaultRepoName)
+// GitHubOptions configures the release client
+type GitHubOptions struct {
+	Token    string // Optional, lifts the limit of 60 requests an hour per IP
+	CacheDir string // Responses kept for conditional requests, empty disables the cache
+}
 
-	// Create HTTP request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+// RateLimitError means GitHub refuses requests until Reset
+type RateLimitError struct {
+	Reset         time.Time
+	Authenticated bool // A token was sent, so asking for one does not help
+	status        *network.HTTPStatusError
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub API rate limit exceeded until %s", e.Reset.Format("15:04"))
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.status
+}
+
+// Permanent stops retries that would not get past the reset anyway
+func (e *RateLimitError) Permanent() bool {
+	return time.Until(e.Reset) > network.DefaultRetryPolicy.MaxDelay
+}
+
+func (e *RateLimitError) Hint() string {
+	if e.Authenticated {
+		return fmt.Sprintf("GitHub rate limits this token. Try again after %s.", e.Reset.Format("15:04"))
 	}
+	return fmt.Sprintf("GitHub allows only a few requests an hour without a token. Try again after %s or set a GitHub token in the update settings.", e.Reset.Format("15:04"))
+}
 
-	// Set headers for GitHub API
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	req.Header.Set("User-Agent", "HyLauncher")
+func (e *RateLimitError) Fields() map[string]any {
+	return map[string]any{"rate_limit_reset": e.Reset, "url": e.status.URL}
+}
 
-	// Make the request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to query GitHub API: %w", err)
+var github = struct {
+	mu           sync.Mutex
+	opts         GitHubOptions
+	limitedUntil time.Time
+	limitErr     *RateLimitError
+}{}
+
+// ConfigureGitHub sets the token and cache of every GitHub request. Without a
+// token HYLAUNCHER_GITHUB_TOKEN is used when set.
+func ConfigureGitHub(opts GitHubOptions) {
+	github.mu.Lock()
+	defer github.mu.Unlock()
+	github.opts = opts
+}
+
+func githubOptions() GitHubOptions {
+	github.mu.Lock()
+	defer github.mu.Unlock()
+
+	opts := github.opts
+	if opts.Token == "" {
+		opts.Token = strings.TrimSpace(os.Getenv("HYLAUNCHER_GITHUB_TOKEN"))
 	}
-	defer resp.Body.Close()
+	return opts
+}
+
+// cachedResponse is a GitHub response kept for If-None-Match
+type cachedResponse struct {
+	URL  string          `json:"url"`
+	ETag string          `json:"etag"`
+	Body json.RawMessage `json:"body"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, resp.Status)
+func githubCachePath(dir, url string) string {
+	sum := sha1.Sum([]byte(url))
+	return filepath.Join(dir, "github-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+func loadGitHubCache(dir, url string) *cachedResponse {
+	if dir == "" {
+		return nil
 	}
 
-	// Decode the release information
-	var release GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return fmt.Errorf("failed to decode GitHub release JSON: %w", err)
+	data, err := os.ReadFile(githubCachePath(dir, url))
+	if err != nil {
+		return nil
 	}
 
-	// Find the requested asset
-	var downloadURL string
-	for _, asset := range release.Assets {
-		if asset.Name == assetName {
-			downloadURL = asset.BrowserDownloadURL
-			break
-		}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil || cached.URL != url {
+		return nil
 	}
+	return &cached
+}
 
-	if downloadURL == "" {
-		return fmt.Errorf("asset '%s' not found in latest release (tag: %s)", assetName, release.TagName)
+func saveGitHubCache(dir string, cached *cachedResponse) {
+	if dir == "" {
+		return
 	}
 
-	// Ensure destination directory exists
-	destDir := filepath.Dir(destPath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
 	}
 
-	// Download the file with progress if reporter is provided
-	if reporter != nil {
-		reporter.Report(stage, 0, fmt.Sprintf("Downloading %s from release %s...", assetName, release.TagName))
+	path := githubCachePath(dir, cached.URL)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Warn("failed to cache GitHub response: %v", err)
+		return
 	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logger.Warn("failed to cache GitHub response: %v", err)
+		return
+	}
+	_ = os.Rename(tmp, path)
+}
 
-	if err := DownloadWithReporter(destPath, downloadURL, assetName, reporter, stage, scaler); err != nil {
-		// Clean up partial download on error
-		_ = os.Remove(destPath)
-		return fmt.Errorf("failed to download %s: %w", assetName, err)
+// githubGet decodes the JSON at url into v. Unchanged responses come from the
+// cache, which also stands in while GitHub rate limits us.
+func githubGet(ctx context.Context, url string, v any) error {
+	opts := githubOptions()
+	cached := loadGitHubCache(opts.CacheDir, url)
+
+	github.mu.Lock()
+	limited := time.Now().Before(github.limitedUntil)
+	limitErr := github.limitErr
+	github.mu.Unlock()
+
+	if limited {
+		if cached != nil {
+			return json.Unmarshal(cached.Body, v)
+		}
+		return limitErr
 	}
 
-	if reporter != nil {
-		reporter.Report(stage, 100, fmt.Sprintf("Downloaded %s successfully", assetName))
+	var body []byte
+	err := network.DefaultRetryPolicy.Do(ctx, func(attempt int) error {
+		var err error
+		body, err = githubFetch(ctx, url, opts.Token, cached)
+		return err
+	})
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		github.mu.Lock()
+		github.limitedUntil = rateErr.Reset
+		github.limitErr = rateErr
+		github.mu.Unlock()
+
+		if cached != nil {
+			logger.Warn("%v, using cached response", err)
+			return json.Unmarshal(cached.Body, v)
+		}
+	}
+	if err != nil {
+		return err
 	}
 
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
 	return nil
 }
 
-func GetLatestReleaseInfo(ctx context.Context) (*GitHubRelease, error) {
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", defaultRepoOwner, defaultRepoName)
+// githubFetch makes one conditional request and returns the body, the
+// cached one when GitHub answers 304
+func githubFetch(ctx context.Context, url, token string, cached *cachedResponse) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, githubTimeout)
+	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("User-Agent", "HyLauncher")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Get().Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query GitHub API: %w", err)
+		return nil, fmt.Errorf("failed to query GitHub API: %w", network.Classify(url, err))
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, resp.Status)
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached.Body, nil
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		if err := rateLimitError(resp); err != nil {
+			err.Authenticated = token != ""
+			return nil, err
+		}
+		fallthrough
+	default:
+		return nil, fmt.Errorf("GitHub API request failed: %w", network.NewHTTPStatusError(resp))
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		saveGitHubCache(githubOptions().CacheDir, &cachedResponse{URL: url, ETag: etag, Body: body})
+	}
+	return body, nil
+}
+
+// rateLimitError reads the primary and secondary rate limit headers, nil
+// means the 403 has another cause
+func rateLimitError(resp *http.Response) *RateLimitError {
+	status := network.NewHTTPStatusError(resp)
+
+	var reset time.Time
+	switch {
+	case status.RetryAfter > 0:
+		reset = time.Now().Add(status.RetryAfter)
+	case resp.Header.Get("X-RateLimit-Remaining") == "0":
+		unix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return nil
+		}
+		reset = time.Unix(unix, 0)
+	default:
+		return nil
+	}
+
+	status.RetryAfter = max(time.Until(reset), time.Second)
+	return &RateLimitError{Reset: reset, status: status}
+}
+
+// GetRelease returns the newest release on channel, drafts excluded
+func GetRelease(ctx context.Context, channel Channel) (*GitHubRelease, error) {
+	urls := endpoints.Get()
+
+	if channel != ChannelPrerelease {
+		var release GitHubRelease
+		if err := githubGet(ctx, urls.LatestReleaseURL(), &release); err != nil {
+			return nil, err
+		}
+		return &release, nil
+	}
+
+	var releases []GitHubRelease
+	if err := githubGet(ctx, urls.ReleasesURL()+"?per_page=30", &releases); err != nil {
+		return nil, err
 	}
 
-	var release GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to decode GitHub release JSON: %w", err)
+	var newest *GitHubRelease
+	for i := range releases {
+		r := &releases[i]
+		if r.Draft {
+			continue
+		}
+		if newest == nil || r.PublishedAt.After(newest.PublishedAt) {
+			newest = r
+		}
 	}
+	if newest == nil {
+		return nil, fmt.Errorf("no published releases found")
+	}
+	return newest, nil
+}
 
-	return &release, nil
+func GetLatestReleaseInfo(ctx context.Context) (*GitHubRelease, error) {
+	return GetRelease(ctx, ChannelStable)
 }
 
 func ListLatestReleaseAssets(ctx context.Context) ([]GitHubReleaseAsset, error) {
@@ -143,3 +348,70 @@ func ListLatestReleaseAssets(ctx context.Context) ([]GitHubReleaseAsset, error)
 	}
 	return release.Assets, nil
 }
+
+// DownloadLatestReleaseAsset downloads an asset from the latest GitHub release
+// If reporter and scaler are nil, downloads silently without progress updates
+func DownloadLatestReleaseAsset(
+	ctx context.Context,
+	assetName string,
+	destPath string,
+	stage progress.Stage,
+	reporter *progress.Reporter,
+	scaler *progress.Scaler,
+) error {
+	return DownloadReleaseAsset(ctx, ChannelStable, assetName, destPath, stage, reporter, scaler)
+}
+
+// DownloadReleaseAsset downloads an asset from the newest release on channel
+func DownloadReleaseAsset(
+	ctx context.Context,
+	channel Channel,
+	assetName string,
+	destPath string,
+	stage progress.Stage,
+	reporter *progress.Reporter,
+	scaler *progress.Scaler,
+) error {
+	release, err := GetRelease(ctx, channel)
+	if err != nil {
+		return err
+	}
+
+	// Find the requested asset
+	var downloadURL string
+	var expected Expected
+	for _, asset := range release.Assets {
+		if asset.Name == assetName {
+			downloadURL = asset.BrowserDownloadURL
+			expected = asset.expected()
+			break
+		}
+	}
+
+	if downloadURL == "" {
+		return fmt.Errorf("asset '%s' not found in release %s", assetName, release.TagName)
+	}
+
+	// Ensure destination directory exists
+	destDir := filepath.Dir(destPath)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	// Download the file with progress if reporter is provided
+	if reporter != nil {
+		reporter.Report(stage, 0, fmt.Sprintf("Downloading %s from release %s...", assetName, release.TagName))
+	}
+
+	if err := DownloadContext(ctx, destPath, downloadURL, assetName, expected, reporter, stage, scaler); err != nil {
+		// Clean up partial download on error
+		_ = os.Remove(destPath)
+		return fmt.Errorf("failed to download %s: %w", assetName, err)
+	}
+
+	if reporter != nil {
+		reporter.Report(stage, 100, fmt.Sprintf("Downloaded %s successfully", assetName))
+	}
+
+	return nil
+}
@@ -2,63 +2,103 @@ package download
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"time"
 
 	"HyLauncher/internal/progress"
+	"HyLauncher/pkg/logging"
+	"HyLauncher/pkg/network"
 )
 
-const (
-	maxRetries     = 5
-	baseRetryDelay = 3 * time.Second
-	downloadLimit  = 45 * time.Minute
-)
+var logger = logging.New("download")
+
+const downloadLimit = 45 * time.Minute
+
+var httpClient network.ClientRef
 
-// DownloadWithReporter is a reliable, tolerant downloader
+// downloadRetry retries a whole download, segments also retry on their own
+// with segmentRetry
+var downloadRetry = network.DefaultRetryPolicy
+
+// SetHTTPClient sends every download and GitHub request through c, nil
+// restores the default client
+func SetHTTPClient(c *http.Client) {
+	httpClient.Set(c)
+}
+
+// DownloadWithReporter is a reliable, tolerant downloader. The result is
+// checked against expected, a mismatch gets one clean re-download before
+// failing with a *ChecksumError.
 func DownloadWithReporter(
 	dest string,
 	url string,
 	fileName string,
+	expected Expected,
+	reporter *progress.Reporter,
+	stage progress.Stage,
+	scaler *progress.Scaler,
+) error {
+	return DownloadContext(context.Background(), dest, url, fileName, expected, reporter, stage, scaler)
+}
+
+// DownloadContext is DownloadWithReporter that stops when ctx is done. The
+// partial file is kept, so a later call resumes it.
+func DownloadContext(
+	ctx context.Context,
+	dest string,
+	url string,
+	fileName string,
+	expected Expected,
 	reporter *progress.Reporter,
 	stage progress.Stage,
 	scaler *progress.Scaler,
 ) error {
 
+	policy := downloadRetry
+
 	var lastErr error
+	redownloaded := false
 
-	for attempt := 1; attempt <= maxRetries; attempt++ {
+	for attempt := 1; attempt <= policy.Attempts; attempt++ {
 		if attempt > 1 {
-			delay := baseRetryDelay * time.Duration(1<<(attempt-2))
-			if delay > 60*time.Second {
-				delay = 60 * time.Second
-			}
-
-			msg := fmt.Sprintf("Retrying download (%d/%d)...", attempt, maxRetries)
+			msg := fmt.Sprintf("Retrying download (%d/%d)...", attempt, policy.Attempts)
 			if scaler != nil {
 				scaler.Report(stage, 0, msg)
 			} else if reporter != nil {
 				reporter.Report(stage, 0, msg)
 			}
 
-			time.Sleep(delay)
+			if err := policy.Wait(ctx, attempt, lastErr); err != nil {
+				return err
+			}
 		}
 
-		err := attemptDownload(dest, url, fileName, reporter, stage, scaler)
+		err := attemptDownload(ctx, dest, url, fileName, expected, reporter, stage, scaler)
 		if err == nil {
 			return nil
 		}
 
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		lastErr = err
-		fmt.Println("Download failed:", err)
+		logger.Warn("Download failed: %v", err)
+
+		if errors.Is(err, ErrChecksumMismatch) {
+			if redownloaded {
+				return err
+			}
+			redownloaded = true
+		} else if !network.Retryable(err) {
+			return err
+		}
 
 		// Windows AV needs a little time
 		if runtime.GOOS == "windows" {
@@ -66,34 +106,42 @@ func DownloadWithReporter(
 		}
 	}
 
-	return fmt.Errorf("download failed after %d attempts: %w", maxRetries, lastErr)
+	return fmt.Errorf("download failed after %d attempts: %w", policy.Attempts, lastErr)
 }
 
 func attemptDownload(
+	ctx context.Context,
 	dest string,
 	url string,
 	fileName string,
+	expected Expected,
 	reporter *progress.Reporter,
 	stage progress.Stage,
 	scaler *progress.Scaler,
 ) error {
 
-	client := createSafeClient()
+	client := httpClient.Get()
 
 	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
 		return err
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, downloadLimit)
+	defer cancel()
+
+	if handled, err := trySegmented(ctx, client, dest, url, fileName, expected, reporter, stage, scaler); handled {
+		return err
+	}
+
 	partPath := dest + ".part"
 
+	// trySegmented already dropped partial data that has no usable state
 	var resumeFrom int64
-	if st, err := os.Stat(partPath); err == nil {
+	state, err := loadPartState(partPath)
+	if st, statErr := os.Stat(partPath); statErr == nil && err == nil {
 		resumeFrom = st.Size()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), downloadLimit)
-	defer cancel()
-
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
@@ -101,35 +149,74 @@ func attemptDownload(
 
 	if resumeFrom > 0 {
 		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", resumeFrom))
+		req.Header.Set("If-Range", state.ifRange())
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return network.Classify(url, err)
+	}
+
+	// The server refused the range, typically 416 for a .part that is already
+	// complete but was never renamed. Start over without it in this attempt,
+	// the status says nothing about the file itself.
+	if resumeFrom > 0 && resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		logger.Debug("Resume of %s refused with status %d, downloading again", url, resp.StatusCode)
+		resp.Body.Close()
+		removePart(partPath)
+		resumeFrom = 0
+
+		req.Header.Del("Range")
+		req.Header.Del("If-Range")
+		resp, err = client.Do(req)
+		if err != nil {
+			return network.Classify(url, err)
+		}
 	}
 	defer resp.Body.Close()
 
 	// Diagnose
-	fmt.Printf(
-		"Download debug: status=%d resume=%v length=%d accept-ranges=%q\n",
+	logger.Debug(
+		"Download debug: status=%d resume=%v length=%d accept-ranges=%q",
 		resp.StatusCode,
 		resumeFrom > 0,
 		resp.ContentLength,
 		resp.Header.Get("Accept-Ranges"),
 	)
 
-	// Resume safety checks
+	// Resume safety checks. When If-Range does not match the server sends the
+	// whole new file with 200, which simply replaces the partial data.
 	if resumeFrom > 0 {
-		if resp.StatusCode != http.StatusPartialContent ||
-			resp.Header.Get("Accept-Ranges") != "bytes" {
-
-			_ = os.Remove(partPath)
+		if resp.StatusCode == http.StatusPartialContent && !state.matches(resp, resumeFrom) {
+			removePart(partPath)
+			return errRemoteChanged
+		}
+		if resp.StatusCode == http.StatusOK {
+			removePart(partPath)
 			resumeFrom = 0
 		}
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
-		return fmt.Errorf("bad HTTP status: %s", resp.Status)
+		return network.NewHTTPStatusError(resp)
+	}
+
+	if resumeFrom == 0 {
+		state = newPartState(url, max(resp.ContentLength, 0), resp)
+		if err := expected.checkSize(url, state.Size); err != nil {
+			removePart(partPath)
+			return err
+		}
+		if err := state.save(partPath); err != nil {
+			logger.Warn("failed to save download state: %v", err)
+		}
+	}
+
+	// The digest covers the whole file, including what an earlier attempt wrote
+	h := newHasher(url, expected)
+	if err := h.hashFile(partPath, resumeFrom); err != nil {
+		removePart(partPath)
+		return err
 	}
 
 	flags := os.O_CREATE | os.O_WRONLY
@@ -145,10 +232,7 @@ func attemptDownload(
 	}
 	defer out.Close()
 
-	total := resp.ContentLength
-	if resumeFrom > 0 && total > 0 {
-		total += resumeFrom
-	}
+	total := state.Size
 
 	buf := make([]byte, 64*1024)
 	downloaded := resumeFrom
@@ -158,9 +242,13 @@ func attemptDownload(
 	for {
 		n, err := resp.Body.Read(buf)
 		if n > 0 {
+			if terr := throttle(ctx, n); terr != nil {
+				return terr
+			}
 			if _, werr := out.Write(buf[:n]); werr != nil {
 				return werr
 			}
+			h.Write(buf[:n])
 
 			downloaded += int64(n)
 			now := time.Now()
@@ -178,6 +266,8 @@ func attemptDownload(
 					reporter.ReportDownload(stage, progressPct, "Downloading...", fileName, formatSpeed(speed), downloaded, total)
 				}
 
+				reportProgress(ctx, downloaded, total)
+
 				lastUpdate = now
 				lastBytes = downloaded
 			}
@@ -188,11 +278,7 @@ func attemptDownload(
 				break
 			}
 
-			if isRetryable(err) {
-				return err
-			}
-
-			return fmt.Errorf("read error: %w", err)
+			return fmt.Errorf("read error: %w", network.Classify(url, err))
 		}
 	}
 
@@ -201,6 +287,16 @@ func attemptDownload(
 	}
 	out.Close()
 
+	// A connection closed early can still end in a clean EOF
+	if total > 0 && downloaded != total {
+		return fmt.Errorf("got %d of %d bytes: %w", downloaded, total, io.ErrUnexpectedEOF)
+	}
+
+	if err := h.verify(downloaded); err != nil {
+		removePart(partPath)
+		return err
+	}
+
 	if runtime.GOOS == "windows" {
 		_ = os.Remove(dest)
 	}
@@ -208,6 +304,9 @@ func attemptDownload(
 	if err := os.Rename(partPath, dest); err != nil {
 		return err
 	}
+	_ = os.Remove(statePath(partPath))
+
+	reportProgress(ctx, downloaded, total)
 
 	if scaler != nil {
 		scaler.ReportDownload(stage, 100, "Download complete", fileName, "", downloaded, total)
@@ -218,41 +317,6 @@ func attemptDownload(
 	return nil
 }
 
-func isRetryable(err error) bool {
-	if errors.Is(err, io.ErrUnexpectedEOF) {
-		return true
-	}
-
-	msg := err.Error()
-	return strings.Contains(msg, "connection reset") ||
-		strings.Contains(msg, "broken pipe") ||
-		strings.Contains(msg, "timeout")
-}
-
-func createSafeClient() *http.Client {
-	dialer := &net.Dialer{
-		Timeout:   60 * time.Second,
-		KeepAlive: 60 * time.Second,
-	}
-
-	transport := &http.Transport{
-		DialContext:           dialer.DialContext,
-		ForceAttemptHTTP2:     false,
-		MaxIdleConns:          10,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   20 * time.Second,
-		ExpectContinueTimeout: 2 * time.Second,
-		TLSClientConfig: &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		},
-		Proxy: http.ProxyFromEnvironment,
-	}
-
-	return &http.Client{
-		Transport: transport,
-	}
-}
-
 func formatSpeed(bytesPerSec float64) string {
 	const unit = 1024
 	if bytesPerSec < unit {
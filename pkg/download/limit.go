@@ -0,0 +1,152 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// limiterBurst matches the read buffer, so a single read never waits for more
+// tokens than the bucket can hold
+const limiterBurst = 64 * 1024
+
+// bandwidth is shared by every download in the process, segments included
+var bandwidth = rate.NewLimiter(rate.Inf, limiterBurst)
+
+// SetRateLimit caps the combined speed of all downloads in bytes per second,
+// 0 removes the cap. Running downloads pick the new value up right away.
+func SetRateLimit(bytesPerSec int64) {
+	if bytesPerSec <= 0 {
+		bandwidth.SetLimit(rate.Inf)
+		return
+	}
+	bandwidth.SetLimit(rate.Limit(bytesPerSec))
+}
+
+// RateLimit returns the current cap in bytes per second, 0 when unlimited
+func RateLimit() int64 {
+	limit := bandwidth.Limit()
+	if limit == rate.Inf {
+		return 0
+	}
+	return int64(limit)
+}
+
+// throttle blocks until n more bytes may be read
+func throttle(ctx context.Context, n int) error {
+	return bandwidth.WaitN(ctx, n)
+}
+
+// Window is a daily time range, such as 01:00-07:00, in local time. It may
+// wrap past midnight. The zero Window is always open.
+type Window struct {
+	start, end time.Duration // Since midnight
+	set        bool
+}
+
+// ParseWindow reads "HH:MM-HH:MM", an empty string gives the always open Window
+func ParseWindow(s string) (Window, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Window{}, nil
+	}
+
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid download window %q, expected HH:MM-HH:MM", s)
+	}
+
+	start, err := parseClock(from)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid download window %q: %w", s, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid download window %q: %w", s, err)
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("invalid download window %q: start and end are equal", s)
+	}
+
+	return Window{start: start, end: end, set: true}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	hours, err := strconv.Atoi(hh)
+	if err != nil || hours < 0 || hours > 23 {
+		return 0, fmt.Errorf("invalid hour in %q", s)
+	}
+	minutes, err := strconv.Atoi(mm)
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("invalid minute in %q", s)
+	}
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+func (w Window) String() string {
+	if !w.set {
+		return ""
+	}
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(w.start) + "-" + clock(w.end)
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return t.Sub(midnight)
+}
+
+// Contains reports whether t falls inside the window
+func (w Window) Contains(t time.Time) bool {
+	if !w.set {
+		return true
+	}
+
+	now := sinceMidnight(t)
+	if w.start < w.end {
+		return now >= w.start && now < w.end
+	}
+	return now >= w.start || now < w.end
+}
+
+// UntilOpen returns how long until the window opens, 0 when it is open
+func (w Window) UntilOpen(t time.Time) time.Duration {
+	if w.Contains(t) {
+		return 0
+	}
+
+	wait := w.start - sinceMidnight(t)
+	if wait < 0 {
+		wait += 24 * time.Hour
+	}
+	return wait
+}
+
+// UntilClose returns how long the window stays open from t, 0 when it is
+// closed. The always open Window never closes and reports -1.
+func (w Window) UntilClose(t time.Time) time.Duration {
+	if !w.set {
+		return -1
+	}
+	if !w.Contains(t) {
+		return 0
+	}
+
+	left := w.end - sinceMidnight(t)
+	if left <= 0 {
+		left += 24 * time.Hour
+	}
+	return left
+}
@@ -0,0 +1,654 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"HyLauncher/internal/progress"
+
+	"github.com/google/uuid"
+)
+
+type JobKind string
+
+const (
+	KindPWR      JobKind = "pwr"
+	KindJRE      JobKind = "jre"
+	KindButler   JobKind = "butler"
+	KindLauncher JobKind = "launcher"
+)
+
+type JobState string
+
+const (
+	JobQueued  JobState = "queued"
+	JobRunning JobState = "running"
+	JobPaused  JobState = "paused"
+	JobFailed  JobState = "failed"
+)
+
+var ErrJobNotFound = errors.New("download job not found")
+
+var (
+	errPaused       = errors.New("download paused")
+	errCanceled     = errors.New("download canceled")
+	errWindowClosed = errors.New("download window closed")
+	errReplaced     = errors.New("download replaced by a newer one")
+)
+
+// errPausedWaiting is what a waiter gets when the user pauses its job. It
+// counts as canceled, so an install waiting for it stops quietly.
+var errPausedWaiting = fmt.Errorf("%w: %w", context.Canceled, errPaused)
+
+// Job is a queued download. Jobs are identified by Dest, queueing the same
+// file twice attaches to the existing job. When the URL or expected digest
+// differ the old job is stale and is replaced.
+type Job struct {
+	ID         string    `json:"id"`
+	Kind       JobKind   `json:"kind"`
+	Name       string    `json:"name"`
+	URL        string    `json:"url"`
+	Dest       string    `json:"dest"`
+	Expected   Expected  `json:"expected"`
+	State      JobState  `json:"state"`
+	Error      string    `json:"error,omitempty"`
+	Downloaded int64     `json:"downloaded"`
+	Total      int64     `json:"total"`
+	Window     string    `json:"window,omitempty"` // Only runs inside this daily window, see ParseWindow
+	Added      time.Time `json:"added"`
+}
+
+// job is a Job with what only lives as long as the process
+type job struct {
+	Job
+
+	reporter *progress.Reporter
+	stage    progress.Stage
+	scaler   *progress.Scaler
+
+	waiters  []chan error
+	cancel   context.CancelCauseFunc
+	window   *time.Timer // Stops the job when its window closes
+	replaced bool        // Stopping because a download of the same file with another URL or digest was queued
+}
+
+// Manager owns the download queue. Jobs run one at a time in queue order and
+// the queue is saved to disk. Unfinished jobs come back paused after a
+// restart and continue once their file is requested again or the user
+// resumes them, so nothing runs for a build that is no longer wanted.
+// Finished and canceled jobs leave the queue, failed ones stay until retried.
+type Manager struct {
+	mu       sync.Mutex
+	saveMu   sync.Mutex // Keeps saves in snapshot order
+	path     string
+	jobs     []*job
+	wake     chan struct{}
+	onChange func([]Job)
+}
+
+func NewManager(path string) *Manager {
+	m := &Manager{path: path, wake: make(chan struct{}, 1)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("failed to read download queue: %v", err)
+		}
+		return m
+	}
+
+	var saved []Job
+	if err := json.Unmarshal(data, &saved); err != nil {
+		logger.Warn("discarding broken download queue: %v", err)
+		return m
+	}
+
+	for _, j := range saved {
+		// Nobody waits for it yet, see Prune for jobs nobody will ask for again
+		if j.State == JobRunning || j.State == JobQueued {
+			j.State = JobPaused
+		}
+		m.jobs = append(m.jobs, &job{Job: j})
+	}
+
+	return m
+}
+
+// OnChange registers fn to receive the queue whenever it or a job's progress changes
+func (m *Manager) OnChange(fn func([]Job)) {
+	m.mu.Lock()
+	m.onChange = fn
+	m.mu.Unlock()
+}
+
+// Jobs returns the queue in order
+func (m *Manager) Jobs() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot()
+}
+
+func (m *Manager) snapshot() []Job {
+	jobs := make([]Job, len(m.jobs))
+	for i, j := range m.jobs {
+		jobs[i] = j.Job
+	}
+	return jobs
+}
+
+// changed saves the queue and tells the listener. Call it without m.mu held.
+func (m *Manager) changed(persist bool) {
+	if persist {
+		m.saveMu.Lock()
+		defer m.saveMu.Unlock()
+	}
+
+	m.mu.Lock()
+	jobs := m.snapshot()
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	if persist {
+		if err := m.save(jobs); err != nil {
+			logger.Warn("failed to save download queue: %v", err)
+		}
+	}
+	if onChange != nil {
+		onChange(jobs)
+	}
+}
+
+func (m *Manager) save(jobs []Job) error {
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *Manager) find(id string) (int, *job) {
+	for i, j := range m.jobs {
+		if j.ID == id {
+			return i, j
+		}
+	}
+	return -1, nil
+}
+
+// Download queues a job and waits for it. Progress goes to reporter while the
+// job runs. When ctx is done first the job is paused, not dropped, so the
+// next Download of the same file resumes it.
+func (m *Manager) Download(
+	ctx context.Context,
+	spec Job,
+	reporter *progress.Reporter,
+	stage progress.Stage,
+	scaler *progress.Scaler,
+) error {
+	done := make(chan error, 1)
+
+	m.mu.Lock()
+	var j *job
+	var stale []chan error
+	for _, existing := range m.jobs {
+		if existing.Dest != spec.Dest || existing.replaced {
+			continue
+		}
+		if existing.URL == spec.URL && existing.Expected == spec.Expected {
+			j = existing
+			break
+		}
+		stale = append(stale, m.replace(existing)...)
+	}
+
+	if j == nil {
+		spec.Window = windowFrom(ctx)
+		spec.ID = uuid.NewString()
+		spec.State = JobQueued
+		spec.Added = time.Now()
+		j = &job{Job: spec}
+		m.jobs = append(m.jobs, j)
+	} else {
+		if j.State == JobPaused || j.State == JobFailed {
+			j.State = JobQueued
+			j.Error = ""
+		}
+		// Someone waiting now beats a background schedule
+		if windowFrom(ctx) == "" {
+			j.Window = ""
+		}
+	}
+
+	j.reporter, j.stage, j.scaler = reporter, stage, scaler
+	j.waiters = append(j.waiters, done)
+	m.mu.Unlock()
+
+	for _, w := range stale {
+		w <- context.Canceled
+	}
+
+	m.changed(true)
+	m.signal()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		m.detach(j, done)
+		return ctx.Err()
+	}
+}
+
+// replace drops a job for the same file that was queued with another URL or
+// digest, such as a persisted one for an older build. It returns the waiters
+// to tell; a running job is stopped and cleaned up by run. m.mu is held.
+func (m *Manager) replace(j *job) []chan error {
+	logger.Info("Replacing stale download of %s from %s", j.Name, j.URL)
+
+	if j.State == JobRunning {
+		j.replaced = true
+		j.cancel(errReplaced)
+		return nil
+	}
+
+	m.remove(j)
+	removePart(j.Dest + ".part")
+
+	waiters := j.waiters
+	j.waiters = nil
+	return waiters
+}
+
+// detach stops waiting on j. A job nobody waits for any more is paused.
+func (m *Manager) detach(j *job, done chan error) {
+	m.mu.Lock()
+	j.waiters = slices.DeleteFunc(j.waiters, func(c chan error) bool { return c == done })
+	if len(j.waiters) > 0 {
+		m.mu.Unlock()
+		return
+	}
+
+	j.reporter, j.scaler = nil, nil
+	switch j.State {
+	case JobRunning:
+		j.cancel(errPaused)
+	case JobQueued:
+		j.State = JobPaused
+	}
+	m.mu.Unlock()
+
+	m.changed(true)
+}
+
+// Run works through the queue until ctx is done
+func (m *Manager) Run(ctx context.Context) {
+	for {
+		j, jctx, wait := m.next(ctx)
+		if j != nil {
+			m.run(jctx, j)
+			continue
+		}
+
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = time.After(wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-timer:
+		}
+	}
+}
+
+// next marks the first queued job whose window is open as running. Without
+// one it returns how long until a window opens, 0 when nothing is waiting.
+func (m *Manager) next(ctx context.Context) (*job, context.Context, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+
+	for _, j := range m.jobs {
+		if j.State != JobQueued {
+			continue
+		}
+
+		window, err := ParseWindow(j.Window)
+		if err != nil {
+			logger.Warn("ignoring invalid window of %s: %v", j.Name, err)
+		}
+		if untilOpen := window.UntilOpen(now); untilOpen > 0 {
+			if wait == 0 || untilOpen < wait {
+				wait = untilOpen
+			}
+			continue
+		}
+
+		jctx, cancel := context.WithCancelCause(ctx)
+		j.window = nil
+		if untilClose := window.UntilClose(now); untilClose > 0 {
+			j.window = time.AfterFunc(untilClose, func() { cancel(errWindowClosed) })
+		}
+
+		j.State = JobRunning
+		j.cancel = cancel
+		return j, jctx, 0
+	}
+
+	return nil, nil, wait
+}
+
+func (m *Manager) run(ctx context.Context, j *job) {
+	m.changed(true)
+
+	m.mu.Lock()
+	reporter, stage, scaler := j.reporter, j.stage, j.scaler
+	m.mu.Unlock()
+
+	ctx = withProgress(ctx, func(downloaded, total int64) {
+		m.mu.Lock()
+		j.Downloaded, j.Total = downloaded, total
+		m.mu.Unlock()
+		m.changed(false)
+	})
+
+	err := DownloadContext(ctx, j.Dest, j.URL, j.Name, j.Expected, reporter, stage, scaler)
+	cause := context.Cause(ctx)
+	j.cancel(nil)
+
+	m.mu.Lock()
+	if j.window != nil {
+		j.window.Stop()
+		j.window = nil
+	}
+
+	var result error
+	notify := true
+
+	switch {
+	case err == nil:
+		m.remove(j)
+
+	case errors.Is(cause, errCanceled), errors.Is(cause, errReplaced):
+		m.remove(j)
+		removePart(j.Dest + ".part")
+		result = context.Canceled
+
+	case errors.Is(cause, errPaused):
+		// Whoever waited when the pause was issued was already told. Someone
+		// who attached while the download was stopping still wants the file.
+		if len(j.waiters) > 0 {
+			j.State = JobQueued
+			notify = false
+		} else {
+			j.State = JobPaused
+		}
+
+	case errors.Is(cause, errWindowClosed):
+		// Picked up again when the window reopens
+		j.State = JobQueued
+		notify = false
+
+	case errors.Is(err, context.Canceled):
+		// The manager is shutting down, the job continues on the next start
+		j.State = JobQueued
+		result = err
+
+	default:
+		j.State = JobFailed
+		j.Error = err.Error()
+		result = err
+	}
+
+	var waiters []chan error
+	if notify {
+		waiters = j.waiters
+		j.waiters = nil
+	}
+	m.mu.Unlock()
+
+	for _, w := range waiters {
+		w <- result
+	}
+	m.changed(true)
+}
+
+func (m *Manager) remove(j *job) {
+	m.jobs = slices.DeleteFunc(m.jobs, func(other *job) bool { return other == j })
+}
+
+// Pause stops a queued or running job, keeping what was downloaded. Whoever
+// waits for it stops waiting right away with an error that matches
+// context.Canceled.
+func (m *Manager) Pause(id string) error {
+	m.mu.Lock()
+	_, j := m.find(id)
+	if j == nil {
+		m.mu.Unlock()
+		return ErrJobNotFound
+	}
+
+	var waiters []chan error
+	switch j.State {
+	case JobRunning:
+		// run marks it paused once the download has stopped
+		j.cancel(errPaused)
+		waiters = j.waiters
+		j.waiters = nil
+	case JobQueued:
+		j.State = JobPaused
+		waiters = j.waiters
+		j.waiters = nil
+	}
+	m.mu.Unlock()
+
+	for _, w := range waiters {
+		w <- errPausedWaiting
+	}
+
+	m.changed(true)
+	return nil
+}
+
+// Resume queues a paused or failed job again
+func (m *Manager) Resume(id string) error {
+	m.mu.Lock()
+	_, j := m.find(id)
+	if j == nil {
+		m.mu.Unlock()
+		return ErrJobNotFound
+	}
+
+	if j.State == JobPaused || j.State == JobFailed {
+		j.State = JobQueued
+		j.Error = ""
+	}
+	m.mu.Unlock()
+
+	m.changed(true)
+	m.signal()
+	return nil
+}
+
+// Cancel drops a job and its partial data. Whoever waits for it gets context.Canceled.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	_, j := m.find(id)
+	if j == nil {
+		m.mu.Unlock()
+		return ErrJobNotFound
+	}
+
+	if j.State == JobRunning {
+		// run cleans up once the download has stopped
+		j.cancel(errCanceled)
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.remove(j)
+	waiters := j.waiters
+	j.waiters = nil
+	m.mu.Unlock()
+
+	removePart(j.Dest + ".part")
+	for _, w := range waiters {
+		w <- context.Canceled
+	}
+
+	m.changed(true)
+	return nil
+}
+
+// Prune drops the jobs of kind that keep rejects along with their partial
+// data, such as a restored download of a build that is no longer current.
+// Whoever waits for them gets context.Canceled.
+func (m *Manager) Prune(kind JobKind, keep func(Job) bool) {
+	m.mu.Lock()
+	var dropped []*job
+	var waiters []chan error
+	for _, j := range slices.Clone(m.jobs) {
+		if j.Kind != kind || keep(j.Job) {
+			continue
+		}
+
+		logger.Info("Dropping stale download of %s from %s", j.Name, j.URL)
+		if j.State == JobRunning {
+			// run cleans up once the download has stopped
+			j.cancel(errCanceled)
+			continue
+		}
+
+		m.remove(j)
+		dropped = append(dropped, j)
+		waiters = append(waiters, j.waiters...)
+		j.waiters = nil
+	}
+	m.mu.Unlock()
+
+	if len(dropped) == 0 {
+		return
+	}
+
+	for _, j := range dropped {
+		removePart(j.Dest + ".part")
+	}
+	for _, w := range waiters {
+		w <- context.Canceled
+	}
+
+	m.changed(true)
+}
+
+// Move puts a job at index in the queue. A running job is not interrupted,
+// the order decides which job starts next.
+func (m *Manager) Move(id string, index int) error {
+	m.mu.Lock()
+	i, j := m.find(id)
+	if j == nil {
+		m.mu.Unlock()
+		return ErrJobNotFound
+	}
+
+	index = max(0, min(index, len(m.jobs)-1))
+	m.jobs = slices.Delete(m.jobs, i, i+1)
+	m.jobs = slices.Insert(m.jobs, index, j)
+	m.mu.Unlock()
+
+	m.changed(true)
+	return nil
+}
+
+type windowKey struct{}
+
+// WithWindow restricts downloads queued with ctx to a daily window such as
+// "01:00-07:00", see ParseWindow
+func WithWindow(ctx context.Context, window string) context.Context {
+	return context.WithValue(ctx, windowKey{}, window)
+}
+
+func windowFrom(ctx context.Context) string {
+	window, _ := ctx.Value(windowKey{}).(string)
+	return window
+}
+
+type progressKey struct{}
+
+// withProgress makes the download engine report byte counts to fn
+func withProgress(ctx context.Context, fn func(downloaded, total int64)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func reportProgress(ctx context.Context, downloaded, total int64) {
+	if fn, ok := ctx.Value(progressKey{}).(func(downloaded, total int64)); ok {
+		fn(downloaded, total)
+	}
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultManager *Manager
+)
+
+// SetDefaultManager routes Download through m
+func SetDefaultManager(m *Manager) {
+	defaultMu.Lock()
+	defaultManager = m
+	defaultMu.Unlock()
+}
+
+// Prune drops stale jobs from the default Manager, see Manager.Prune
+func Prune(kind JobKind, keep func(Job) bool) {
+	defaultMu.RLock()
+	m := defaultManager
+	defaultMu.RUnlock()
+
+	if m != nil {
+		m.Prune(kind, keep)
+	}
+}
+
+// Download runs spec through the default Manager, or directly when none is set
+func Download(
+	ctx context.Context,
+	spec Job,
+	reporter *progress.Reporter,
+	stage progress.Stage,
+	scaler *progress.Scaler,
+) error {
+	defaultMu.RLock()
+	m := defaultManager
+	defaultMu.RUnlock()
+
+	if m == nil {
+		return DownloadContext(ctx, spec.Dest, spec.URL, spec.Name, spec.Expected, reporter, stage, scaler)
+	}
+	return m.Download(ctx, spec, reporter, stage, scaler)
+}
@@ -0,0 +1,305 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer serves body for every path. Requests hold until release is
+// called, so a test can act while a download is in flight.
+type testServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	started  chan struct{} // Receives once per request
+	release  func()
+}
+
+func newTestServer(t *testing.T, body []byte, gated bool) *testServer {
+	t.Helper()
+
+	gate := make(chan struct{})
+	var once sync.Once
+	s := &testServer{started: make(chan struct{}, 64), release: func() { once.Do(func() { close(gate) }) }}
+	if !gated {
+		s.release()
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		select {
+		case s.started <- struct{}{}:
+		default:
+		}
+
+		select {
+		case <-gate:
+		case <-r.Context().Done():
+			return
+		}
+		http.ServeContent(w, r, "file", time.Unix(1700000000, 0), bytes.NewReader(body))
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(s.release) // Runs first, handlers must return before Close
+	return s
+}
+
+// startManager runs a Manager on a queue file in dir until the test ends
+func startManager(t *testing.T, dir string) *Manager {
+	t.Helper()
+
+	m := NewManager(filepath.Join(dir, "downloads.json"))
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+	return m
+}
+
+// writeQueue stores jobs as a previous run would have left them
+func writeQueue(t *testing.T, dir string, jobs []Job) {
+	t.Helper()
+
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "downloads.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func receive(t *testing.T, errc <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("download did not return")
+		return nil
+	}
+}
+
+func assertContent(t *testing.T, path string, want []byte) {
+	t.Helper()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: got %d bytes, want %d", path, len(got), len(want))
+	}
+}
+
+var testBody = bytes.Repeat([]byte("hytale"), 1024)
+
+func TestManagerDownload(t *testing.T) {
+	dir := t.TempDir()
+	srv := newTestServer(t, testBody, false)
+	m := startManager(t, dir)
+
+	dest := filepath.Join(dir, "1.pwr")
+	err := m.Download(context.Background(), Job{Kind: KindPWR, Name: "1.pwr", URL: srv.URL + "/1.pwr", Dest: dest}, nil, "", nil)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	assertContent(t, dest, testBody)
+	if jobs := m.Jobs(); len(jobs) != 0 {
+		t.Errorf("finished job still queued: %+v", jobs)
+	}
+}
+
+func TestManagerSharesJob(t *testing.T) {
+	dir := t.TempDir()
+	srv := newTestServer(t, testBody, true)
+	m := startManager(t, dir)
+
+	spec := Job{Kind: KindJRE, Name: "jre.tar.gz", URL: srv.URL + "/jre.tar.gz", Dest: filepath.Join(dir, "jre.tar.gz")}
+	errc := make(chan error, 2)
+	go func() { errc <- m.Download(context.Background(), spec, nil, "", nil) }()
+	<-srv.started
+	go func() { errc <- m.Download(context.Background(), spec, nil, "", nil) }()
+
+	waitFor(t, "second waiter", func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.jobs) == 1 && len(m.jobs[0].waiters) == 2
+	})
+	srv.release()
+
+	for range 2 {
+		if err := receive(t, errc); err != nil {
+			t.Errorf("download: %v", err)
+		}
+	}
+	assertContent(t, spec.Dest, testBody)
+}
+
+func TestManagerPauseReleasesWaiters(t *testing.T) {
+	dir := t.TempDir()
+	srv := newTestServer(t, testBody, true)
+	m := startManager(t, dir)
+
+	spec := Job{Kind: KindPWR, Name: "2.pwr", URL: srv.URL + "/2.pwr", Dest: filepath.Join(dir, "2.pwr")}
+	errc := make(chan error, 1)
+	go func() { errc <- m.Download(context.Background(), spec, nil, "", nil) }()
+	<-srv.started
+
+	if err := m.Pause(m.Jobs()[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	err := receive(t, errc)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errPaused) {
+		t.Errorf("got %v, want a paused error matching context.Canceled", err)
+	}
+	waitFor(t, "paused state", func() bool {
+		jobs := m.Jobs()
+		return len(jobs) == 1 && jobs[0].State == JobPaused
+	})
+}
+
+// TestManagerReattachWhilePausing is an install taking over from a cancelled
+// pre-download: it asks for the same file before the paused job has stopped
+func TestManagerReattachWhilePausing(t *testing.T) {
+	dir := t.TempDir()
+	srv := newTestServer(t, testBody, true)
+	m := startManager(t, dir)
+
+	spec := Job{Kind: KindPWR, Name: "3.pwr", URL: srv.URL + "/3.pwr", Dest: filepath.Join(dir, "3.pwr")}
+
+	preCtx, cancelPre := context.WithCancel(context.Background())
+	preErr := make(chan error, 1)
+	go func() { preErr <- m.Download(preCtx, spec, nil, "", nil) }()
+	<-srv.started
+
+	cancelPre()
+	if err := receive(t, preErr); !errors.Is(err, context.Canceled) {
+		t.Fatalf("pre-download: got %v, want context.Canceled", err)
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- m.Download(context.Background(), spec, nil, "", nil) }()
+	srv.release()
+
+	if err := receive(t, errc); err != nil {
+		t.Fatalf("install download: %v", err)
+	}
+	assertContent(t, spec.Dest, testBody)
+}
+
+func TestManagerReplacesStaleJob(t *testing.T) {
+	dir := t.TempDir()
+	srv := newTestServer(t, testBody, false)
+
+	dest := filepath.Join(dir, "jre.tar.gz")
+	writeQueue(t, dir, []Job{{ID: "old", Kind: KindJRE, Name: "jre.tar.gz", URL: srv.URL + "/old", Dest: dest, State: JobPaused}})
+	if err := os.WriteFile(dest+".part", []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := startManager(t, dir)
+	err := m.Download(context.Background(), Job{Kind: KindJRE, Name: "jre.tar.gz", URL: srv.URL + "/new", Dest: dest}, nil, "", nil)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	assertContent(t, dest, testBody)
+	if jobs := m.Jobs(); len(jobs) != 0 {
+		t.Errorf("stale job still queued: %+v", jobs)
+	}
+}
+
+func TestManagerRestoredJobsWait(t *testing.T) {
+	dir := t.TempDir()
+	srv := newTestServer(t, testBody, false)
+
+	writeQueue(t, dir, []Job{
+		{ID: "a", Kind: KindPWR, Name: "4.pwr", URL: srv.URL + "/4.pwr", Dest: filepath.Join(dir, "4.pwr"), State: JobRunning},
+		{ID: "b", Kind: KindPWR, Name: "5.pwr", URL: srv.URL + "/5.pwr", Dest: filepath.Join(dir, "5.pwr"), State: JobQueued},
+	})
+
+	m := startManager(t, dir)
+	for _, j := range m.Jobs() {
+		if j.State != JobPaused {
+			t.Errorf("restored job %s is %s, want paused", j.ID, j.State)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n := srv.requests.Load(); n != 0 {
+		t.Errorf("restored jobs made %d requests without anyone asking", n)
+	}
+
+	// Asking for the file again continues it
+	if err := m.Download(context.Background(), Job{Kind: KindPWR, Name: "5.pwr", URL: srv.URL + "/5.pwr", Dest: filepath.Join(dir, "5.pwr")}, nil, "", nil); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if jobs := m.Jobs(); len(jobs) != 1 || jobs[0].ID != "a" {
+		t.Errorf("queue is %+v, want only job a", jobs)
+	}
+}
+
+func TestManagerPrune(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "6.pwr")
+	current := filepath.Join(dir, "7.pwr")
+
+	writeQueue(t, dir, []Job{
+		{ID: "stale", Kind: KindPWR, Name: "6.pwr", URL: "http://example.invalid/6.pwr", Dest: stale, State: JobPaused},
+		{ID: "current", Kind: KindPWR, Name: "7.pwr", URL: "http://example.invalid/7.pwr", Dest: current, State: JobPaused},
+		{ID: "jre", Kind: KindJRE, Name: "jre.zip", URL: "http://example.invalid/jre.zip", Dest: filepath.Join(dir, "jre.zip"), State: JobFailed},
+	})
+	if err := os.WriteFile(stale+".part", []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(filepath.Join(dir, "downloads.json"))
+	m.Prune(KindPWR, func(j Job) bool { return j.Dest == current })
+
+	var ids []string
+	for _, j := range m.Jobs() {
+		ids = append(ids, j.ID)
+	}
+	if len(ids) != 2 || ids[0] != "current" || ids[1] != "jre" {
+		t.Errorf("queue is %v, want [current jre]", ids)
+	}
+	if _, err := os.Stat(stale + ".part"); !os.IsNotExist(err) {
+		t.Errorf("partial data of the stale job kept: %v", err)
+	}
+
+	// The queue on disk agrees
+	if ids := NewManager(filepath.Join(dir, "downloads.json")).Jobs(); len(ids) != 2 {
+		t.Errorf("saved queue holds %d jobs, want 2", len(ids))
+	}
+}
@@ -0,0 +1,129 @@
+package download
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// partState is persisted next to the .part file. It records what the partial
+// data belongs to, so a resume never splices two versions of a file, and the
+// segment map of a segmented download.
+type partState struct {
+	URL          string     `json:"url"`
+	Size         int64      `json:"size"` // 0 when the server did not send a length
+	ETag         string     `json:"etag,omitempty"`
+	LastModified string     `json:"lastModified,omitempty"`
+	Segments     []*segment `json:"segments,omitempty"`
+
+	mu sync.Mutex
+}
+
+// newPartState records the validators of the response the data comes from
+func newPartState(url string, size int64, resp *http.Response) *partState {
+	return &partState{
+		URL:          url,
+		Size:         size,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
+// ifRange returns the validator to send as If-Range. Weak ETags are not
+// allowed there, Last-Modified is the fallback. "" means a resume can not be
+// validated and has to start over.
+func (s *partState) ifRange() string {
+	if s.ETag != "" && !strings.HasPrefix(s.ETag, "W/") {
+		return s.ETag
+	}
+	return s.LastModified
+}
+
+// matches reports whether a 206 response continues the file the state describes
+func (s *partState) matches(resp *http.Response, offset int64) bool {
+	if etag := resp.Header.Get("ETag"); s.ETag != "" && etag != "" && etag != s.ETag {
+		return false
+	}
+
+	start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+	if !ok || start != offset {
+		return false
+	}
+	return s.Size == 0 || size == s.Size
+}
+
+func statePath(partPath string) string {
+	return partPath + ".json"
+}
+
+func loadPartState(partPath string) (*partState, error) {
+	data, err := os.ReadFile(statePath(partPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var state partState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	for _, seg := range state.Segments {
+		seg.written = seg.Done
+	}
+	return &state, nil
+}
+
+func (s *partState) save(partPath string) error {
+	s.mu.Lock()
+	data, err := json.Marshal(s)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := statePath(partPath) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, statePath(partPath))
+}
+
+func removePart(partPath string) {
+	_ = os.Remove(partPath)
+	_ = os.Remove(statePath(partPath))
+}
+
+// parseContentRange reads "bytes <start>-<end>/<size>". An unknown size, "*",
+// is reported as 0.
+func parseContentRange(value string) (start, size int64, ok bool) {
+	rest, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+
+	span, total, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	first, _, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if total != "*" {
+		size, err = strconv.ParseInt(total, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return start, size, true
+}
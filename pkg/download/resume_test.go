@@ -0,0 +1,114 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// writePart leaves data and its state behind as an interrupted single
+// stream download of url would
+func writePart(t *testing.T, dest string, data []byte, state *partState) {
+	t.Helper()
+
+	if err := os.WriteFile(dest+".part", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.save(dest + ".part"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResumeSendsIfRange(t *testing.T) {
+	body := randomBody(256 << 10)
+	srv := newFileServer(t, body, `"v1"`)
+	url := srv.URL + "/jre.zip"
+	dest := filepath.Join(t.TempDir(), "jre.zip")
+
+	half := len(body) / 2
+	writePart(t, dest, body[:half], &partState{URL: url, Size: int64(len(body)), ETag: `"v1"`})
+
+	if err := download(t, dest, url, Expected{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+	assertNoPart(t, dest)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.requests) != 1 {
+		t.Fatalf("%d requests, want 1", len(srv.requests))
+	}
+	req := srv.requests[0]
+	if got, want := req.Header.Get("Range"), "bytes="+strconv.Itoa(half)+"-"; got != want {
+		t.Errorf("Range %q, want %q", got, want)
+	}
+	if got := req.Header.Get("If-Range"); got != `"v1"` {
+		t.Errorf("If-Range %q, want the stored ETag", got)
+	}
+	if served := srv.served.Load(); served != int64(len(body)-half) {
+		t.Errorf("served %d bytes, want only the missing %d", served, len(body)-half)
+	}
+}
+
+func TestResumeRestartsWhenRemoteChanged(t *testing.T) {
+	old := randomBody(200 << 10)
+	body := randomBody(300 << 10)
+	srv := newFileServer(t, old, `"v1"`)
+	url := srv.URL + "/jre.zip"
+	dest := filepath.Join(t.TempDir(), "jre.zip")
+
+	writePart(t, dest, old[:len(old)/2], &partState{URL: url, Size: int64(len(old)), ETag: `"v1"`})
+
+	// A new build under the same URL, If-Range no longer matches
+	srv.change(body, `"v2"`)
+
+	if err := download(t, dest, url, Expected{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+	if served := srv.served.Load(); served != int64(len(body)) {
+		t.Errorf("served %d bytes, want the whole new file of %d", served, len(body))
+	}
+}
+
+func TestResumeRefusedStartsOver(t *testing.T) {
+	body := randomBody(64 << 10)
+	srv := newFileServer(t, body, `"v1"`)
+	url := srv.URL + "/jre.zip"
+	dest := filepath.Join(t.TempDir(), "jre.zip")
+
+	// Longer than the file, the server answers the range with 416
+	writePart(t, dest, append(randomBody(len(body)), 0, 0, 0), &partState{URL: url, ETag: `"v1"`})
+
+	if err := download(t, dest, url, Expected{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+
+	if ranges := srv.ranges(); len(ranges) != 2 || ranges[1] != "" {
+		t.Errorf("requests %q, want the refused range then a full download", ranges)
+	}
+}
+
+func TestResumeWithoutValidatorStartsOver(t *testing.T) {
+	body := randomBody(64 << 10)
+	srv := newFileServer(t, body, `"v1"`)
+	url := srv.URL + "/jre.zip"
+	dest := filepath.Join(t.TempDir(), "jre.zip")
+
+	// Nothing proves the partial data belongs to the current file
+	writePart(t, dest, randomBody(1000), &partState{URL: url, Size: int64(len(body))})
+
+	if err := download(t, dest, url, Expected{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+
+	for _, r := range srv.ranges() {
+		if r != "" && r != "bytes=0-0" {
+			t.Errorf("resumed with Range %q", r)
+		}
+	}
+}
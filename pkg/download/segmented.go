@@ -0,0 +1,439 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"HyLauncher/internal/progress"
+	"HyLauncher/pkg/network"
+)
+
+const (
+	segmentCount      = 4
+	segmentThreshold  = 32 << 20 // Smaller files are not worth the extra connections
+	stateSaveInterval = time.Second
+)
+
+// segmentRetry is shorter than the policy of the whole download, which
+// retries again once a segment gives up
+var segmentRetry = network.RetryPolicy{
+	Attempts:  3,
+	BaseDelay: 3 * time.Second,
+	MaxDelay:  10 * time.Second,
+}
+
+// errRangesUnsupported means the server answered a range request with the
+// whole file, so the download has to fall back to a single stream
+var errRangesUnsupported = errors.New("server does not support range requests")
+
+// errRemoteChanged means the validators no longer match the partial data, so
+// the download has to start over
+var errRemoteChanged = errors.New("remote file changed since the download started")
+
+// segment is a byte range of the file fetched by its own connection
+type segment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`  // Inclusive
+	Done  int64 `json:"done"` // Bytes known to be on disk, see partState.sync
+
+	written int64 // Bytes written so far, possibly not synced yet
+}
+
+func (s *segment) complete() bool {
+	return s.Start+s.Done > s.End
+}
+
+// splitSegments divides the file into count ranges of about equal size
+func (s *partState) splitSegments(count int) {
+	chunk := s.Size / int64(count)
+	for i := 0; i < count; i++ {
+		start := int64(i) * chunk
+		end := start + chunk - 1
+		if i == count-1 {
+			end = s.Size - 1
+		}
+		s.Segments = append(s.Segments, &segment{Start: start, End: end})
+	}
+}
+
+func (s *partState) advance(seg *segment, n int64) {
+	s.mu.Lock()
+	seg.written += n
+	s.mu.Unlock()
+}
+
+func (s *partState) offset(seg *segment) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return seg.Start + seg.written
+}
+
+// sync flushes out to disk and moves Done up to what was written before the
+// flush, so a saved map never claims bytes that could still be lost
+func (s *partState) sync(out *os.File) error {
+	s.mu.Lock()
+	written := make([]int64, len(s.Segments))
+	for i, seg := range s.Segments {
+		written[i] = seg.written
+	}
+	s.mu.Unlock()
+
+	if err := out.Sync(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for i, seg := range s.Segments {
+		seg.Done = written[i]
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *partState) downloaded() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total int64
+	for _, seg := range s.Segments {
+		total += seg.written
+	}
+	return total
+}
+
+// trySegmented runs the download as parallel range requests when a segment map
+// exists or the server supports ranges. It reports false when the caller should
+// stream the file over a single connection instead.
+func trySegmented(
+	ctx context.Context,
+	client *http.Client,
+	dest string,
+	url string,
+	fileName string,
+	expected Expected,
+	reporter *progress.Reporter,
+	stage progress.Stage,
+	scaler *progress.Scaler,
+) (bool, error) {
+	partPath := dest + ".part"
+
+	state, err := loadPartState(partPath)
+	switch {
+	case err != nil:
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("discarding unreadable download state: %v", err)
+		}
+		// Partial data we know nothing about can not be resumed safely
+		removePart(partPath)
+		state = nil
+	case state.URL != url || state.ifRange() == "":
+		removePart(partPath)
+		state = nil
+	case len(state.Segments) == 0:
+		// Left by a single stream, which resumes it
+		return false, nil
+	}
+
+	for restarted := false; ; restarted = true {
+		if state == nil {
+			state, err = probeRanges(ctx, client, url)
+			if err != nil {
+				return true, err
+			}
+			if state == nil || state.Size < segmentThreshold {
+				return false, nil
+			}
+			if err := expected.checkSize(url, state.Size); err != nil {
+				return true, err
+			}
+			state.splitSegments(segmentCount)
+		}
+
+		err = downloadSegments(ctx, client, dest, state, fileName, expected, reporter, stage, scaler)
+		switch {
+		case errors.Is(err, errRemoteChanged) && !restarted:
+			logger.Info("Remote file changed, restarting download")
+			removePart(partPath)
+			state = nil
+		case errors.Is(err, errRangesUnsupported):
+			removePart(partPath)
+			return false, nil
+		default:
+			return true, err
+		}
+	}
+}
+
+// probeRanges asks for the first byte to learn whether the server serves
+// ranges, how large the file is and which validators identify it. It returns
+// nil when the server does not support ranges.
+func probeRanges(ctx context.Context, client *http.Client, url string) (*partState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, network.Classify(url, err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return nil, nil
+	default:
+		return nil, network.NewHTTPStatusError(resp)
+	}
+
+	_, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+	if !ok || size <= 0 {
+		return nil, nil
+	}
+
+	return newPartState(url, size, resp), nil
+}
+
+func downloadSegments(
+	ctx context.Context,
+	client *http.Client,
+	dest string,
+	state *partState,
+	fileName string,
+	expected Expected,
+	reporter *progress.Reporter,
+	stage progress.Stage,
+	scaler *progress.Scaler,
+) error {
+	partPath := dest + ".part"
+
+	out, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// Segments write at their own offsets, so the file needs its final size up front
+	if err := out.Truncate(state.Size); err != nil {
+		return err
+	}
+	if err := state.save(partPath); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	for _, seg := range state.Segments {
+		if seg.complete() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(seg *segment) {
+			defer wg.Done()
+			if err := fetchSegment(ctx, client, state, seg, out); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(seg)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	lastUpdate := time.Now()
+	lastBytes := state.downloaded()
+	lastSave := lastUpdate
+
+wait:
+	for {
+		select {
+		case <-done:
+			break wait
+		case now := <-ticker.C:
+			downloaded := state.downloaded()
+			speed := float64(downloaded-lastBytes) / now.Sub(lastUpdate).Seconds()
+			progressPct := float64(downloaded) / float64(state.Size) * 100
+
+			if scaler != nil {
+				scaler.ReportDownload(stage, progressPct, "Downloading...", fileName, formatSpeed(speed), downloaded, state.Size)
+			} else if reporter != nil {
+				reporter.ReportDownload(stage, progressPct, "Downloading...", fileName, formatSpeed(speed), downloaded, state.Size)
+			}
+
+			reportProgress(ctx, downloaded, state.Size)
+
+			lastUpdate = now
+			lastBytes = downloaded
+
+			if now.Sub(lastSave) >= stateSaveInterval {
+				// Data has to reach the disk before the map claims it is there
+				if err := state.sync(out); err == nil {
+					_ = state.save(partPath)
+				}
+				lastSave = now
+			}
+		}
+	}
+
+	if err := state.sync(out); err != nil {
+		return err
+	}
+	if err := state.save(partPath); err != nil {
+		logger.Warn("failed to save segment map: %v", err)
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	out.Close()
+
+	// Segments arrive out of order, so the digest is taken once they are all in
+	h := newHasher(state.URL, expected)
+	if h.active() {
+		if scaler != nil {
+			scaler.Report(stage, 100, "Verifying download...")
+		} else if reporter != nil {
+			reporter.Report(stage, 100, "Verifying download...")
+		}
+	}
+	if err := h.hashFile(partPath, state.Size); err != nil {
+		return err
+	}
+	if err := h.verify(state.Size); err != nil {
+		removePart(partPath)
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		_ = os.Remove(dest)
+	}
+
+	if err := os.Rename(partPath, dest); err != nil {
+		return err
+	}
+	_ = os.Remove(statePath(partPath))
+
+	reportProgress(ctx, state.Size, state.Size)
+
+	if scaler != nil {
+		scaler.ReportDownload(stage, 100, "Download complete", fileName, "", state.Size, state.Size)
+	} else if reporter != nil {
+		reporter.ReportDownload(stage, 100, "Download complete", fileName, "", state.Size, state.Size)
+	}
+
+	return nil
+}
+
+// fetchSegment downloads what is left of seg, retrying on its own so one
+// flaky connection does not restart the others
+func fetchSegment(ctx context.Context, client *http.Client, state *partState, seg *segment, out *os.File) error {
+	var err error
+	for attempt := 1; attempt <= segmentRetry.Attempts; attempt++ {
+		if attempt > 1 {
+			if werr := segmentRetry.Wait(ctx, attempt, err); werr != nil {
+				return werr
+			}
+		}
+
+		err = fetchRange(ctx, client, state, seg, out)
+		if err == nil || ctx.Err() != nil || errors.Is(err, errRangesUnsupported) || errors.Is(err, errRemoteChanged) || !network.Retryable(err) {
+			return err
+		}
+		logger.Warn("Segment %d-%d failed: %v", seg.Start, seg.End, err)
+	}
+
+	return fmt.Errorf("segment %d-%d failed after %d attempts: %w", seg.Start, seg.End, segmentRetry.Attempts, err)
+}
+
+func fetchRange(ctx context.Context, client *http.Client, state *partState, seg *segment, out *os.File) error {
+	offset := state.offset(seg)
+	if offset > seg.End {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, state.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, seg.End))
+	if validator := state.ifRange(); validator != "" {
+		req.Header.Set("If-Range", validator)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return network.Classify(state.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		// With If-Range a full response means the validator did not match
+		if state.ifRange() != "" {
+			return errRemoteChanged
+		}
+		return errRangesUnsupported
+	}
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// The remote file is shorter than the one the state describes
+		return errRemoteChanged
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return network.NewHTTPStatusError(resp)
+	}
+	if !state.matches(resp, offset) {
+		return errRemoteChanged
+	}
+
+	body := io.LimitReader(resp.Body, seg.End-offset+1)
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if terr := throttle(ctx, n); terr != nil {
+				return terr
+			}
+			if _, werr := out.WriteAt(buf[:n], offset); werr != nil {
+				return werr
+			}
+			offset += int64(n)
+			state.advance(seg, int64(n))
+		}
+
+		if err == io.EOF {
+			if offset <= seg.End {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read error: %w", network.Classify(state.URL, err))
+		}
+	}
+}
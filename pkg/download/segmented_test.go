@@ -0,0 +1,270 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fileServer serves body under etag and records what every request asked for
+type fileServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	etag     string
+	body     []byte
+	requests []*http.Request
+	served   atomic.Int64 // Body bytes sent
+}
+
+func newFileServer(t *testing.T, body []byte, etag string) *fileServer {
+	t.Helper()
+
+	s := &fileServer{etag: etag, body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Clone(context.Background()))
+		etag, body := s.etag, s.body
+		s.mu.Unlock()
+
+		w.Header().Set("ETag", etag)
+		http.ServeContent(&countingWriter{ResponseWriter: w, n: &s.served}, r, "file", time.Unix(1700000000, 0), bytes.NewReader(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// change replaces the file, as a new build published under the same URL
+func (s *fileServer) change(body []byte, etag string) {
+	s.mu.Lock()
+	s.body, s.etag = body, etag
+	s.mu.Unlock()
+}
+
+// ranges returns the Range header of every request, "" for a full one
+func (s *fileServer) ranges() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ranges := make([]string, len(s.requests))
+	for i, r := range s.requests {
+		ranges[i] = r.Header.Get("Range")
+	}
+	return ranges
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	n *atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n.Add(int64(n))
+	return n, err
+}
+
+func randomBody(size int) []byte {
+	body := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(body)
+	return body
+}
+
+func download(t *testing.T, dest, url string, expected Expected) error {
+	t.Helper()
+	return DownloadContext(context.Background(), dest, url, filepath.Base(dest), expected, nil, "", nil)
+}
+
+func assertNoPart(t *testing.T, dest string) {
+	t.Helper()
+
+	for _, path := range []string{dest + ".part", statePath(dest + ".part")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s left behind: %v", path, err)
+		}
+	}
+}
+
+func TestSegmentedDownload(t *testing.T) {
+	body := randomBody(segmentThreshold + 12345)
+	srv := newFileServer(t, body, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "1.pwr")
+
+	if err := download(t, dest, srv.URL+"/1.pwr", Expected{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+	assertNoPart(t, dest)
+
+	// A one byte probe, then one request per segment
+	var segments int
+	for _, r := range srv.ranges() {
+		if r != "" && r != "bytes=0-0" {
+			segments++
+		}
+	}
+	if segments != segmentCount {
+		t.Errorf("%d segment requests, want %d: %v", segments, segmentCount, srv.ranges())
+	}
+}
+
+func TestSegmentedFallsBackWithoutRanges(t *testing.T) {
+	body := randomBody(segmentThreshold + 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignores Range like a server without support for it
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "1.pwr")
+	if err := download(t, dest, srv.URL, Expected{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+}
+
+func TestSegmentMapPersistence(t *testing.T) {
+	partPath := filepath.Join(t.TempDir(), "1.pwr.part")
+	out, err := os.Create(partPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	state := &partState{URL: "http://example.invalid/1.pwr", Size: 4000, ETag: `"v1"`}
+	state.splitSegments(segmentCount)
+	state.advance(state.Segments[0], 100)
+	state.advance(state.Segments[2], 300)
+
+	// Written but not synced yet, the saved map must not claim it
+	if err := state.save(partPath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadPartState(partPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, seg := range loaded.Segments {
+		if seg.Done != 0 {
+			t.Errorf("segment %d: unsynced map claims %d bytes", i, seg.Done)
+		}
+	}
+
+	if err := state.sync(out); err != nil {
+		t.Fatal(err)
+	}
+	// Arrives after the sync, so only the next one covers it
+	state.advance(state.Segments[0], 50)
+	if err := state.save(partPath); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err = loadPartState(partPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.URL != state.URL || loaded.Size != state.Size || loaded.ETag != state.ETag {
+		t.Errorf("validators not kept: %+v", loaded)
+	}
+	if len(loaded.Segments) != segmentCount {
+		t.Fatalf("%d segments, want %d", len(loaded.Segments), segmentCount)
+	}
+
+	want := []int64{100, 0, 300, 0}
+	for i, seg := range loaded.Segments {
+		if seg.Done != want[i] {
+			t.Errorf("segment %d: done %d, want %d", i, seg.Done, want[i])
+		}
+		// A resume continues from what the map claims
+		if got := loaded.offset(seg); got != seg.Start+want[i] {
+			t.Errorf("segment %d: resumes at %d, want %d", i, got, seg.Start+want[i])
+		}
+	}
+	if last := loaded.Segments[segmentCount-1]; last.End != state.Size-1 {
+		t.Errorf("last segment ends at %d, want %d", last.End, state.Size-1)
+	}
+}
+
+func TestSegmentedResume(t *testing.T) {
+	body := randomBody(segmentThreshold + 4096)
+	srv := newFileServer(t, body, `"v1"`)
+	url := srv.URL + "/1.pwr"
+	dest := filepath.Join(t.TempDir(), "1.pwr")
+	partPath := dest + ".part"
+
+	// Half of every segment is on disk, the rest of the file is garbage a
+	// resume must overwrite
+	state := &partState{URL: url, Size: int64(len(body)), ETag: `"v1"`}
+	state.splitSegments(segmentCount)
+	part := bytes.Repeat([]byte{0xff}, len(body))
+	var resumed int64
+	for _, seg := range state.Segments {
+		seg.Done = (seg.End - seg.Start + 1) / 2
+		copy(part[seg.Start:seg.Start+seg.Done], body[seg.Start:])
+		resumed += seg.Done
+	}
+	if err := os.WriteFile(partPath, part, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.save(partPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := download(t, dest, url, Expected{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+	assertNoPart(t, dest)
+
+	if served := srv.served.Load(); served != int64(len(body))-resumed {
+		t.Errorf("served %d bytes, want only the missing %d", served, int64(len(body))-resumed)
+	}
+
+	var want []string
+	for _, seg := range state.Segments {
+		want = append(want, fmt.Sprintf("bytes=%d-%d", seg.Start+seg.Done, seg.End))
+	}
+	got := srv.ranges()
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("requested %v, want %v", got, want)
+	}
+}
+
+func TestSegmentedRestartsWhenRemoteChanged(t *testing.T) {
+	old := randomBody(segmentThreshold + 100)
+	body := randomBody(segmentThreshold + 200)
+	srv := newFileServer(t, body, `"v2"`)
+	url := srv.URL + "/1.pwr"
+	dest := filepath.Join(t.TempDir(), "1.pwr")
+
+	// A map of the previous build, same URL
+	state := &partState{URL: url, Size: int64(len(old)), ETag: `"v1"`}
+	state.splitSegments(segmentCount)
+	for _, seg := range state.Segments {
+		seg.Done = 10
+	}
+	if err := os.WriteFile(dest+".part", old, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.save(dest + ".part"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := download(t, dest, url, Expected{}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+}
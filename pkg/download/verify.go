@@ -0,0 +1,139 @@
+package download
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Expected describes the file a download has to produce. Zero fields are not checked.
+type Expected struct {
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"` // Hex encoded
+	SHA1   string `json:"sha1,omitempty"`   // Hex encoded
+}
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksumError reports a download that does not match its Expected size or digest
+type ChecksumError struct {
+	URL      string
+	Kind     string // "size", "sha256" or "sha1"
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s mismatch for %s: expected %s, got %s", e.Kind, e.URL, e.Expected, e.Actual)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// checkSize compares the length the server announced with the expected one,
+// so a wrong file fails before it is downloaded
+func (e Expected) checkSize(url string, size int64) error {
+	if e.Size > 0 && size > 0 && size != e.Size {
+		return &ChecksumError{
+			URL:      url,
+			Kind:     "size",
+			Expected: strconv.FormatInt(e.Size, 10),
+			Actual:   strconv.FormatInt(size, 10),
+		}
+	}
+	return nil
+}
+
+// hasher computes the digests Expected asks for while data is written
+type hasher struct {
+	url      string
+	expected Expected
+	sha256   hash.Hash
+	sha1     hash.Hash
+	w        io.Writer
+}
+
+func newHasher(url string, expected Expected) *hasher {
+	h := &hasher{url: url, expected: expected}
+
+	var writers []io.Writer
+	if expected.SHA256 != "" {
+		h.sha256 = sha256.New()
+		writers = append(writers, h.sha256)
+	}
+	if expected.SHA1 != "" {
+		h.sha1 = sha1.New()
+		writers = append(writers, h.sha1)
+	}
+
+	h.w = io.Discard
+	if len(writers) > 0 {
+		h.w = io.MultiWriter(writers...)
+	}
+	return h
+}
+
+func (h *hasher) Write(p []byte) (int, error) {
+	return h.w.Write(p)
+}
+
+func (h *hasher) active() bool {
+	return h.sha256 != nil || h.sha1 != nil
+}
+
+// hashFile feeds the first n bytes of path, the part a resumed download
+// already has on disk
+func (h *hasher) hashFile(path string, n int64) error {
+	if !h.active() || n == 0 {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.CopyN(h, f, n)
+	return err
+}
+
+// verify checks the final size and digests
+func (h *hasher) verify(size int64) error {
+	if h.expected.Size > 0 && size != h.expected.Size {
+		return &ChecksumError{
+			URL:      h.url,
+			Kind:     "size",
+			Expected: strconv.FormatInt(h.expected.Size, 10),
+			Actual:   strconv.FormatInt(size, 10),
+		}
+	}
+
+	if h.sha256 != nil {
+		if err := h.compare("sha256", h.expected.SHA256, h.sha256); err != nil {
+			return err
+		}
+	}
+	if h.sha1 != nil {
+		if err := h.compare("sha1", h.expected.SHA1, h.sha1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *hasher) compare(kind, expected string, sum hash.Hash) error {
+	actual := hex.EncodeToString(sum.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return &ChecksumError{URL: h.url, Kind: kind, Expected: expected, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package download
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fastRetry makes a retried download start again right away
+func fastRetry(t *testing.T) {
+	t.Helper()
+
+	saved := downloadRetry
+	downloadRetry.BaseDelay = time.Millisecond
+	downloadRetry.MaxDelay = time.Millisecond
+	t.Cleanup(func() { downloadRetry = saved })
+}
+
+// withoutProbes drops the one byte requests that check for range support
+func withoutProbes(ranges []string) []string {
+	var requests []string
+	for _, r := range ranges {
+		if r != "bytes=0-0" {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+func digests(body []byte) Expected {
+	sum256 := sha256.Sum256(body)
+	sum1 := sha1.Sum(body)
+	return Expected{
+		Size:   int64(len(body)),
+		SHA256: hex.EncodeToString(sum256[:]),
+		SHA1:   hex.EncodeToString(sum1[:]),
+	}
+}
+
+func TestDownloadVerifiesDigests(t *testing.T) {
+	body := randomBody(100 << 10)
+	srv := newFileServer(t, body, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "butler.zip")
+
+	if err := download(t, dest, srv.URL+"/butler.zip", digests(body)); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+}
+
+func TestSegmentedVerifiesDigest(t *testing.T) {
+	body := randomBody(segmentThreshold + 100)
+	srv := newFileServer(t, body, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "1.pwr")
+
+	expected := digests(body)
+	expected.SHA1 = ""
+	if err := download(t, dest, srv.URL+"/1.pwr", expected); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+}
+
+// TestDownloadHashesResumedPrefix checks the digest covers the part an
+// earlier attempt wrote, without downloading it again
+func TestDownloadHashesResumedPrefix(t *testing.T) {
+	body := randomBody(100 << 10)
+	srv := newFileServer(t, body, `"v1"`)
+	url := srv.URL + "/jre.zip"
+	dest := filepath.Join(t.TempDir(), "jre.zip")
+
+	half := len(body) / 2
+	writePart(t, dest, body[:half], &partState{URL: url, Size: int64(len(body)), ETag: `"v1"`})
+
+	if err := download(t, dest, url, digests(body)); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+
+	if ranges := withoutProbes(srv.ranges()); len(ranges) != 1 || ranges[0] == "" {
+		t.Errorf("requests %q, want a single resumed range", ranges)
+	}
+}
+
+func TestDownloadRedownloadsAfterMismatch(t *testing.T) {
+	fastRetry(t)
+
+	body := randomBody(100 << 10)
+	srv := newFileServer(t, body, `"v1"`)
+	url := srv.URL + "/jre.zip"
+	dest := filepath.Join(t.TempDir(), "jre.zip")
+
+	// Validators match but the bytes on disk rotted
+	half := len(body) / 2
+	writePart(t, dest, randomBody(half), &partState{URL: url, Size: int64(len(body)), ETag: `"v1"`})
+
+	if err := download(t, dest, url, digests(body)); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertContent(t, dest, body)
+
+	if ranges := withoutProbes(srv.ranges()); len(ranges) != 2 || ranges[0] == "" || ranges[1] != "" {
+		t.Errorf("requests %q, want the resume then one clean download", ranges)
+	}
+}
+
+func TestDownloadChecksumMismatchFails(t *testing.T) {
+	fastRetry(t)
+
+	body := randomBody(100 << 10)
+	srv := newFileServer(t, body, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "jre.zip")
+
+	expected := digests(body)
+	expected.SHA256 = hex.EncodeToString(make([]byte, sha256.Size))
+
+	err := download(t, dest, srv.URL+"/jre.zip", expected)
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) || !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v, want a *ChecksumError", err)
+	}
+	if checksumErr.Kind != "sha256" || checksumErr.Expected != expected.SHA256 {
+		t.Errorf("got %+v", checksumErr)
+	}
+
+	// One clean re-download, then it gives up
+	if ranges := withoutProbes(srv.ranges()); len(ranges) != 2 {
+		t.Errorf("%d requests, want 2", len(ranges))
+	}
+	assertNoPart(t, dest)
+}
+
+func TestDownloadSizeMismatchFails(t *testing.T) {
+	fastRetry(t)
+
+	body := randomBody(10 << 10)
+	srv := newFileServer(t, body, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "jre.zip")
+
+	err := download(t, dest, srv.URL+"/jre.zip", Expected{Size: int64(len(body)) + 1})
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) || checksumErr.Kind != "size" {
+		t.Fatalf("got %v, want a size *ChecksumError", err)
+	}
+}
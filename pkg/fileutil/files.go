@@ -96,3 +96,22 @@ func copyAndDelete(src, dst string) error {
 	sourceFile.Close()
 	return os.Remove(src)
 }
+
+// DirSize returns the total size of all regular files under path
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
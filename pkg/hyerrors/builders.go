@@ -16,8 +16,9 @@ func Network(message string) *Error {
 	return New(CategoryNetwork, SeverityError, message)
 }
 
+// Validation errors reject input the user just entered
 func Validation(message string) *Error {
-	return New(CategoryValidation, SeverityWarning, message)
+	return New(CategoryValidation, SeverityWarning, message).ByUser()
 }
 
 func FileSystem(message string) *Error {
@@ -0,0 +1,76 @@
+package hyerrors
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+)
+
+// Class is what an error chain is reported as
+type Class struct {
+	Category Category
+	Severity Severity
+	Code     Code
+}
+
+// Classifier recognizes the errors of one package, such as its sentinels or
+// typed errors, anywhere in a chain
+type Classifier func(err error) (Class, bool)
+
+var classifiers struct {
+	mu   sync.RWMutex
+	list []Classifier
+}
+
+// builtin recognizes errors from the standard library. They come first, a
+// full disk is the real problem even when it broke a download.
+var builtin = []Classifier{
+	func(err error) (Class, bool) {
+		if isDiskFull(err) {
+			return Class{CategoryFileSystem, SeverityCritical, CodeDiskFull}, true
+		}
+		return Class{}, false
+	},
+	func(err error) (Class, bool) {
+		if errors.Is(err, fs.ErrPermission) {
+			return Class{CategoryFileSystem, SeverityError, CodePermissionDenied}, true
+		}
+		return Class{}, false
+	},
+}
+
+// RegisterClassifier adds fn to the classifiers Wrap consults, in the order
+// they were registered
+func RegisterClassifier(fn Classifier) {
+	classifiers.mu.Lock()
+	defer classifiers.mu.Unlock()
+	classifiers.list = append(classifiers.list, fn)
+}
+
+// RegisterSentinel reports every chain that contains target as class
+func RegisterSentinel(target error, class Class) {
+	RegisterClassifier(func(err error) (Class, bool) {
+		if errors.Is(err, target) {
+			return class, true
+		}
+		return Class{}, false
+	})
+}
+
+// Classify returns the class of the first classifier that recognizes err
+func Classify(err error) (Class, bool) {
+	if err == nil {
+		return Class{}, false
+	}
+
+	classifiers.mu.RLock()
+	list := append(append([]Classifier{}, builtin...), classifiers.list...)
+	classifiers.mu.RUnlock()
+
+	for _, classify := range list {
+		if class, ok := classify(err); ok {
+			return class, true
+		}
+	}
+	return Class{}, false
+}
@@ -0,0 +1,310 @@
+package hyerrors
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Code identifies an error for the frontend, unlike Message it never changes
+// between releases
+type Code string
+
+const (
+	CodeUnknown Code = "UNKNOWN"
+
+	CodeGameError         Code = "GAME_ERROR"
+	CodeGameInstallFailed Code = "GAME_INSTALL_FAILED"
+	CodeGameLaunchFailed  Code = "GAME_LAUNCH_FAILED"
+	CodeGameCorrupted     Code = "GAME_CORRUPTED"
+
+	CodeJavaError    Code = "JAVA_ERROR"
+	CodeJavaNotFound Code = "JAVA_NOT_FOUND"
+	CodeJavaBroken   Code = "JAVA_BROKEN"
+
+	CodeButlerNotFound Code = "BUTLER_NOT_FOUND"
+	CodeButlerBroken   Code = "BUTLER_BROKEN"
+
+	CodeNetError            Code = "NET_ERROR"
+	CodeNetPatchUnreachable Code = "NET_PATCH_UNREACHABLE"
+	CodeNetTimeout          Code = "NET_TIMEOUT"
+	CodeNetDNS              Code = "NET_DNS"
+	CodeNetTLS              Code = "NET_TLS"
+	CodeNetProxyFailed      Code = "NET_PROXY_FAILED"
+	CodeNetRateLimited      Code = "NET_RATE_LIMITED"
+
+	CodeInvalidInput Code = "INVALID_INPUT"
+
+	CodeFSError          Code = "FS_ERROR"
+	CodeDiskFull         Code = "DISK_FULL"
+	CodePermissionDenied Code = "PERMISSION_DENIED"
+
+	CodeConfigError      Code = "CONFIG_ERROR"
+	CodeConfigSaveFailed Code = "CONFIG_SAVE_FAILED"
+	CodeConfigLoadFailed Code = "CONFIG_LOAD_FAILED"
+
+	CodeUpdateError          Code = "UPDATE_ERROR"
+	CodeUpdateCheckFailed    Code = "UPDATE_CHECK_FAILED"
+	CodeUpdateDownloadFailed Code = "UPDATE_DOWNLOAD_FAILED"
+	CodeUpdateVerifyFailed   Code = "UPDATE_VERIFY_FAILED"
+	CodeUpdateHelperFailed   Code = "UPDATE_HELPER_FAILED"
+)
+
+// Action is a fix the UI can offer next to an error. Some run in the
+// backend through App.RunFixAction, the others are handled by the UI.
+type Action string
+
+const (
+	ActionNone          Action = ""
+	ActionRetry         Action = "retry"         // UI: repeat what failed
+	ActionOpenSettings  Action = "open_settings" // UI: show the launcher settings
+	ActionVerifyGame    Action = "verify_game"   // Check the installed game files
+	ActionRepairJava    Action = "repair_java"   // Reinstall a missing or broken JRE
+	ActionRepairButler  Action = "repair_butler" // Reinstall a missing or broken butler
+	ActionCleanupJREs   Action = "cleanup_jres"  // Remove JREs nothing uses to free space
+	ActionOpenAppFolder Action = "open_folder"   // Show the launcher folder to fix it by hand
+)
+
+// Text is a string in several languages, keyed by language tag. English is
+// always present and used when a language is missing.
+type Text map[string]string
+
+func (t Text) In(lang string) string {
+	if s, ok := t[lang]; ok {
+		return s
+	}
+	return t["en"]
+}
+
+// CatalogEntry tells the UI how to present a code. The UI translates
+// TitleKey and HintKey and falls back to Title and Hint for languages the
+// catalog has no text in.
+type CatalogEntry struct {
+	Code     Code     `json:"code"`
+	Category Category `json:"category"`
+	Title    Text     `json:"title"`
+	TitleKey string   `json:"title_key"`
+	Hint     Text     `json:"hint,omitempty"`
+	HintKey  string   `json:"hint_key,omitempty"`
+	Action   Action   `json:"action,omitempty"`
+}
+
+// messageKey is the translation key of a catalog text, stable like the code
+func messageKey(code Code, field string) string {
+	return "errors." + string(code) + "." + field
+}
+
+var catalog = map[Code]CatalogEntry{
+	CodeUnknown: {
+		Category: CategoryUnknown,
+		Title:    Text{"en": "Something went wrong"},
+		Hint:     Text{"en": "Try again. If it keeps failing, export a support bundle and report the problem."},
+	},
+
+	CodeGameError: {
+		Category: CategoryGame,
+		Title:    Text{"en": "Game error"},
+	},
+	CodeGameInstallFailed: {
+		Category: CategoryGame,
+		Title:    Text{"en": "The game could not be installed"},
+		Hint:     Text{"en": "Try again. Downloads resume where they stopped."},
+		Action:   ActionRetry,
+	},
+	CodeGameLaunchFailed: {
+		Category: CategoryGame,
+		Title:    Text{"en": "The game could not be started"},
+		Hint:     Text{"en": "Verify the game files. If they are damaged, install the game again."},
+		Action:   ActionVerifyGame,
+	},
+	CodeGameCorrupted: {
+		Category: CategoryGame,
+		Title:    Text{"en": "Game files are damaged"},
+		Hint:     Text{"en": "Install the game again to replace the damaged files."},
+		Action:   ActionRetry,
+	},
+
+	CodeJavaError: {
+		Category: CategoryJava,
+		Title:    Text{"en": "Java error"},
+	},
+	CodeJavaNotFound: {
+		Category: CategoryJava,
+		Title:    Text{"en": "Java is not installed"},
+		Hint:     Text{"en": "The launcher can download the Java runtime the game needs."},
+		Action:   ActionRepairJava,
+	},
+	CodeJavaBroken: {
+		Category: CategoryJava,
+		Title:    Text{"en": "Java installation is damaged"},
+		Hint:     Text{"en": "Reinstall the Java runtime. Antivirus software sometimes removes its files."},
+		Action:   ActionRepairJava,
+	},
+
+	CodeButlerNotFound: {
+		Category: CategoryGame,
+		Title:    Text{"en": "The patch tool is not installed"},
+		Hint:     Text{"en": "The launcher can download butler, which it uses to apply game updates."},
+		Action:   ActionRepairButler,
+	},
+	CodeButlerBroken: {
+		Category: CategoryGame,
+		Title:    Text{"en": "The patch tool is damaged"},
+		Hint:     Text{"en": "Reinstall butler. Antivirus software sometimes removes its files."},
+		Action:   ActionRepairButler,
+	},
+
+	CodeNetError: {
+		Category: CategoryNetwork,
+		Title:    Text{"en": "Network error"},
+		Hint:     Text{"en": "Check your internet connection and try again."},
+		Action:   ActionRetry,
+	},
+	CodeNetPatchUnreachable: {
+		Category: CategoryNetwork,
+		Title:    Text{"en": "Cannot reach the game servers"},
+		Hint:     Text{"en": "Check your internet connection and proxy settings, then try again."},
+		Action:   ActionRetry,
+	},
+	CodeNetTimeout: {
+		Category: CategoryNetwork,
+		Title:    Text{"en": "The connection timed out"},
+		Hint:     Text{"en": "The server or your connection is slow. Try again later."},
+		Action:   ActionRetry,
+	},
+	CodeNetDNS: {
+		Category: CategoryNetwork,
+		Title:    Text{"en": "Server address could not be resolved"},
+		Hint:     Text{"en": "Check your internet connection and DNS settings."},
+		Action:   ActionRetry,
+	},
+	CodeNetTLS: {
+		Category: CategoryNetwork,
+		Title:    Text{"en": "Secure connection failed"},
+		Hint:     Text{"en": "Check that your system clock is correct. Antivirus or a company proxy may intercept connections."},
+	},
+	CodeNetProxyFailed: {
+		Category: CategoryNetwork,
+		Title:    Text{"en": "The proxy does not work"},
+		Hint:     Text{"en": "Check the proxy address and credentials in the settings."},
+		Action:   ActionOpenSettings,
+	},
+	CodeNetRateLimited: {
+		Category: CategoryNetwork,
+		Title:    Text{"en": "Too many requests to GitHub"},
+		Hint:     Text{"en": "Wait a while, or add a GitHub token in the update settings."},
+		Action:   ActionOpenSettings,
+	},
+
+	CodeInvalidInput: {
+		Category: CategoryValidation,
+		Title:    Text{"en": "Invalid input"},
+	},
+
+	CodeFSError: {
+		Category: CategoryFileSystem,
+		Title:    Text{"en": "File error"},
+		Action:   ActionOpenAppFolder,
+	},
+	CodeDiskFull: {
+		Category: CategoryFileSystem,
+		Title:    Text{"en": "Not enough disk space"},
+		Hint:     Text{"en": "Free some space on the drive with the launcher folder. Unused Java runtimes can be removed."},
+		Action:   ActionCleanupJREs,
+	},
+	CodePermissionDenied: {
+		Category: CategoryFileSystem,
+		Title:    Text{"en": "Access denied"},
+		Hint:     Text{"en": "The launcher cannot write to its folder. Check the folder permissions or your antivirus."},
+		Action:   ActionOpenAppFolder,
+	},
+
+	CodeConfigError: {
+		Category: CategoryConfig,
+		Title:    Text{"en": "Settings error"},
+	},
+	CodeConfigSaveFailed: {
+		Category: CategoryConfig,
+		Title:    Text{"en": "Settings could not be saved"},
+		Hint:     Text{"en": "Check that the launcher folder is writable."},
+		Action:   ActionOpenAppFolder,
+	},
+	CodeConfigLoadFailed: {
+		Category: CategoryConfig,
+		Title:    Text{"en": "Settings could not be read"},
+		Hint:     Text{"en": "The settings file may be damaged. Defaults are used until it is fixed."},
+		Action:   ActionOpenAppFolder,
+	},
+
+	CodeUpdateError: {
+		Category: CategoryUpdate,
+		Title:    Text{"en": "Update error"},
+	},
+	CodeUpdateCheckFailed: {
+		Category: CategoryUpdate,
+		Title:    Text{"en": "Could not check for launcher updates"},
+		Hint:     Text{"en": "Check your internet connection. The launcher keeps working without the update."},
+		Action:   ActionRetry,
+	},
+	CodeUpdateDownloadFailed: {
+		Category: CategoryUpdate,
+		Title:    Text{"en": "The launcher update could not be downloaded"},
+		Action:   ActionRetry,
+	},
+	CodeUpdateVerifyFailed: {
+		Category: CategoryUpdate,
+		Title:    Text{"en": "The launcher update is damaged"},
+		Hint:     Text{"en": "The download did not match its checksum and was discarded. Try again."},
+		Action:   ActionRetry,
+	},
+	CodeUpdateHelperFailed: {
+		Category: CategoryUpdate,
+		Title:    Text{"en": "The launcher update could not be installed"},
+		Hint:     Text{"en": "Download the latest release from GitHub and install it by hand."},
+	},
+}
+
+// defaultCodes is the code an error gets from its category until a builder
+// sets a more specific one
+var defaultCodes = map[Category]Code{
+	CategoryGame:       CodeGameError,
+	CategoryJava:       CodeJavaError,
+	CategoryNetwork:    CodeNetError,
+	CategoryValidation: CodeInvalidInput,
+	CategoryFileSystem: CodeFSError,
+	CategoryConfig:     CodeConfigError,
+	CategoryUpdate:     CodeUpdateError,
+	CategoryUnknown:    CodeUnknown,
+}
+
+func defaultCode(category Category) Code {
+	if code, ok := defaultCodes[category]; ok {
+		return code
+	}
+	return CodeUnknown
+}
+
+// Lookup returns the catalog entry of code
+func Lookup(code Code) (CatalogEntry, bool) {
+	entry, ok := catalog[code]
+	if ok {
+		entry.Code = code
+		entry.TitleKey = messageKey(code, "title")
+		if len(entry.Hint) > 0 {
+			entry.HintKey = messageKey(code, "hint")
+		}
+	}
+	return entry, ok
+}
+
+// Catalog returns every entry, sorted by code
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(catalog))
+	for code := range catalog {
+		entry, _ := Lookup(code)
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b CatalogEntry) int {
+		return cmp.Compare(a.Code, b.Code)
+	})
+	return entries
+}
@@ -0,0 +1,49 @@
+package hyerrors
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	keys := make(map[string]Code)
+	for _, entry := range Catalog() {
+		if entry.Title["en"] == "" {
+			t.Errorf("%s: no English title", entry.Code)
+		}
+		if len(entry.Hint) > 0 && entry.Hint["en"] == "" {
+			t.Errorf("%s: hint without English text", entry.Code)
+		}
+		if entry.TitleKey == "" || (len(entry.Hint) > 0) != (entry.HintKey != "") {
+			t.Errorf("%s: keys %q and %q do not match its texts", entry.Code, entry.TitleKey, entry.HintKey)
+		}
+
+		// Translations are looked up by key, two codes must never share one
+		for _, key := range []string{entry.TitleKey, entry.HintKey} {
+			if other, ok := keys[key]; ok && key != "" {
+				t.Errorf("%s and %s share the key %q", entry.Code, other, key)
+			}
+			keys[key] = entry.Code
+		}
+	}
+}
+
+func TestWithCodeHint(t *testing.T) {
+	err := WrapGame(errors.New("exit status 1"), "failed to launch game").WithCode(CodeGameLaunchFailed)
+	entry, _ := Lookup(CodeGameLaunchFailed)
+	if err.Hint != entry.Hint.In("en") || err.HintKey != entry.HintKey || err.Action != entry.Action {
+		t.Errorf("got hint %q (%q) and action %q, want the catalog ones", err.Hint, err.HintKey, err.Action)
+	}
+
+	// A more specific code replaces the catalog hint of the previous one
+	err = err.WithCode(CodeJavaBroken)
+	if entry, _ := Lookup(CodeJavaBroken); err.HintKey != entry.HintKey {
+		t.Errorf("hint key %q, want %q", err.HintKey, entry.HintKey)
+	}
+
+	// A hint of its own has no translation and stays
+	err = err.WithHint("Close the other instance first.").WithCode(CodeGameLaunchFailed)
+	if err.Hint != "Close the other instance first." || err.HintKey != "" {
+		t.Errorf("own hint replaced: %q (%q)", err.Hint, err.HintKey)
+	}
+}
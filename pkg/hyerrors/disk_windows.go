@@ -0,0 +1,17 @@
+//go:build windows
+
+package hyerrors
+
+import (
+	"errors"
+	"syscall"
+)
+
+const (
+	errorHandleDiskFull syscall.Errno = 39  // ERROR_HANDLE_DISK_FULL
+	errorDiskFull       syscall.Errno = 112 // ERROR_DISK_FULL
+)
+
+func isDiskFull(err error) bool {
+	return errors.Is(err, errorDiskFull) || errors.Is(err, errorHandleDiskFull)
+}
@@ -1,9 +1,14 @@
 package hyerrors
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"runtime"
 	"time"
+
+	"HyLauncher/pkg/trace"
 )
 
 type Severity int
@@ -31,13 +36,27 @@ const (
 type Error struct {
 	ID        string    `json:"id"`
 	Category  Category  `json:"category"`
+	Code      Code      `json:"code"`
 	Severity  Severity  `json:"severity"`
 	Message   string    `json:"message"`
 	Details   string    `json:"details,omitempty"`
+	Hint      string    `json:"hint,omitempty"`
+	HintKey   string    `json:"hint_key,omitempty"` // Set when Hint comes from the catalog
+	Action    Action    `json:"action,omitempty"`
 	Cause     error     `json:"-"`
 	Timestamp time.Time `json:"timestamp"`
 	Stack     []Frame   `json:"stack,omitempty"`
 	Context   Context   `json:"context,omitempty"`
+	TraceID   string    `json:"trace_id,omitempty"` // Operation the error happened in
+	SpanID    string    `json:"span_id,omitempty"`
+
+	// Occurrences is how many times the error was reported since it was last
+	// delivered, set by the registry
+	Occurrences int `json:"occurrences,omitempty"`
+
+	// UserInitiated marks failures of something the user just asked for, the
+	// registry delivers every one of them instead of counting repeats
+	UserInitiated bool `json:"user_initiated,omitempty"`
 }
 
 type Frame struct {
@@ -52,6 +71,7 @@ func New(category Category, severity Severity, message string) *Error {
 	return &Error{
 		ID:        generateID(),
 		Category:  category,
+		Code:      defaultCode(category),
 		Severity:  severity,
 		Message:   message,
 		Timestamp: time.Now(),
@@ -68,9 +88,31 @@ func Wrap(err error, category Category, message string) *Error {
 	e := New(category, SeverityError, message)
 	e.Cause = err
 	e.Details = err.Error()
+
+	var h hinted
+	if errors.As(err, &h) {
+		e.Hint = h.Hint()
+		for key, value := range h.Fields() {
+			e.Context[key] = value
+		}
+	}
+
+	// Report what the cause is, not where it surfaced
+	if class, ok := Classify(err); ok {
+		e.Category = class.Category
+		e.Severity = class.Severity
+		e = e.WithCode(class.Code)
+	}
 	return e
 }
 
+// hinted is implemented by causes that know what the user can do about them,
+// such as the typed errors of pkg/network
+type hinted interface {
+	Hint() string
+	Fields() map[string]any
+}
+
 func (e *Error) Error() string {
 	if e.Details != "" {
 		return fmt.Sprintf("%s: %s", e.Message, e.Details)
@@ -88,6 +130,64 @@ func (e *Error) WithDetails(details string) *Error {
 	return e
 }
 
+// WithHint replaces the catalog hint with text that has no translation key
+func (e *Error) WithHint(hint string) *Error {
+	e.Hint = hint
+	e.HintKey = ""
+	return e
+}
+
+// WithCode sets the stable code the frontend looks up in the catalog. The
+// catalog hint, with its translation key, and fix action are used unless the
+// error has its own.
+func (e *Error) WithCode(code Code) *Error {
+	previous, _ := Lookup(e.Code)
+	e.Code = code
+
+	entry, _ := Lookup(code)
+	if e.Hint == "" || e.Hint == previous.Hint.In("en") {
+		e.Hint = entry.Hint.In("en")
+		e.HintKey = entry.HintKey
+	}
+	if e.Action == ActionNone || e.Action == previous.Action {
+		e.Action = entry.Action
+	}
+	return e
+}
+
+// WithTrace ties the error to the operation running in ctx, so the crash
+// report can include its trace
+func (e *Error) WithTrace(ctx context.Context) *Error {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return e
+	}
+
+	e.TraceID = span.TraceID
+	e.SpanID = span.ID
+	span.Record("error", e.Error())
+	return e
+}
+
+func (e *Error) WithSeverity(severity Severity) *Error {
+	e.Severity = severity
+	return e
+}
+
+// ByUser marks the error as the answer to a user action, such as pressing
+// play or running a fix, so a retry that fails again is shown again
+func (e *Error) ByUser() *Error {
+	e.UserInitiated = true
+	return e
+}
+
+// Fingerprint identifies repeats of the same error, whatever their details
+func (e *Error) Fingerprint() string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", e.Category, e.Code, e.Message)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
 func (e *Error) IsCritical() bool {
 	return e.Severity == SeverityCritical
 }
@@ -114,5 +214,5 @@ func captureStack(skip int) []Frame {
 }
 
 func generateID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	return trace.NewID()
 }
@@ -1,6 +1,14 @@
 package hyerrors
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"HyLauncher/pkg/logging"
+)
+
+var logger = logging.New("errors")
 
 type Handler interface {
 	Handle(err *Error)
@@ -12,12 +20,28 @@ func (f HandlerFunc) Handle(err *Error) {
 	f(err)
 }
 
+// DefaultSuppressWindow is how long repeats of an error are counted instead
+// of delivered
+const DefaultSuppressWindow = 30 * time.Second
+
+// Registry fans reported errors out to its handlers. The first error of a
+// fingerprint is delivered right away, repeats within the suppress window
+// are counted and delivered once as the last of them with Occurrences set.
+// Critical and user initiated errors are never held back.
 type Registry struct {
 	mu       sync.RWMutex
 	handlers []Handler
+	window   time.Duration
+	seen     map[string]*occurrence
 }
 
-var global = &Registry{}
+// occurrence tracks a fingerprint inside its suppress window
+type occurrence struct {
+	last       *Error
+	suppressed int
+}
+
+var global = &Registry{window: DefaultSuppressWindow, seen: make(map[string]*occurrence)}
 
 func RegisterHandler(h Handler) {
 	global.mu.Lock()
@@ -35,21 +59,121 @@ func ClearHandlers() {
 	global.handlers = nil
 }
 
+// SetSuppressWindow changes how long repeats are held back, 0 delivers every error
+func SetSuppressWindow(window time.Duration) {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.window = window
+}
+
 func Handle(err *Error) {
+	global.handle(err)
+}
+
+func Report(err *Error) {
+	Handle(err)
+}
+
+func (r *Registry) handle(err *Error) {
 	if err == nil {
 		return
 	}
 
-	global.mu.RLock()
-	handlers := make([]Handler, len(global.handlers))
-	copy(handlers, global.handlers)
-	global.mu.RUnlock()
+	fingerprint := err.Fingerprint()
+
+	r.mu.Lock()
+	if r.window > 0 && !err.IsCritical() && !err.UserInitiated {
+		if o, ok := r.seen[fingerprint]; ok {
+			o.last = err
+			o.suppressed++
+			r.mu.Unlock()
+			return
+		}
+		r.seen[fingerprint] = &occurrence{}
+		time.AfterFunc(r.window, func() { r.flush(fingerprint) })
+	}
+	handlers := r.snapshot()
+	r.mu.Unlock()
+
+	err.Occurrences = 1
+	deliver(handlers, err)
+}
+
+// flush ends the suppress window of fingerprint. Repeats seen during it are
+// delivered as one error and a new window starts, so a steady failure is
+// reported once per window.
+func (r *Registry) flush(fingerprint string) {
+	r.mu.Lock()
+	o, ok := r.seen[fingerprint]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	if o.suppressed == 0 {
+		delete(r.seen, fingerprint)
+		r.mu.Unlock()
+		return
+	}
+
+	err := o.last
+	err.Occurrences = o.suppressed
+	o.last, o.suppressed = nil, 0
+	time.AfterFunc(r.window, func() { r.flush(fingerprint) })
+
+	handlers := r.snapshot()
+	r.mu.Unlock()
 
+	deliver(handlers, err)
+}
+
+// snapshot copies the handlers, r.mu is held
+func (r *Registry) snapshot() []Handler {
+	handlers := make([]Handler, len(r.handlers))
+	copy(handlers, r.handlers)
+	return handlers
+}
+
+func deliver(handlers []Handler, err *Error) {
 	for _, h := range handlers {
 		h.Handle(err)
 	}
 }
 
-func Report(err *Error) {
-	Handle(err)
+// asyncHandler runs a slow handler on its own goroutine
+type asyncHandler struct {
+	handler Handler
+	queue   chan *Error
+	dropped atomic.Int64
+}
+
+// Async returns a handler that queues errors for h and returns right away,
+// for handlers that write files or talk to the frontend. When more than
+// buffer errors are waiting, new ones are counted and dropped, except
+// critical ones which h then handles on the caller's goroutine. h must be
+// safe for concurrent use.
+func Async(h Handler, buffer int) Handler {
+	a := &asyncHandler{handler: h, queue: make(chan *Error, buffer)}
+	go func() {
+		for err := range a.queue {
+			h.Handle(err)
+		}
+	}()
+	return a
+}
+
+func (a *asyncHandler) Handle(err *Error) {
+	select {
+	case a.queue <- err:
+		return
+	default:
+	}
+
+	// Blocking on the queue could deadlock when h reports errors itself
+	if err.IsCritical() {
+		a.handler.Handle(err)
+		return
+	}
+
+	n := a.dropped.Add(1)
+	logger.Warn("error queue full, dropped [%s] %s (%d dropped so far)", err.Code, err.Error(), n)
 }
@@ -0,0 +1,110 @@
+package hyerrors
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// collect is a handler that keeps what it was given
+type collect struct {
+	mu     sync.Mutex
+	errors []*Error
+}
+
+func (c *collect) Handle(err *Error) {
+	c.mu.Lock()
+	c.errors = append(c.errors, err)
+	c.mu.Unlock()
+}
+
+func (c *collect) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errors)
+}
+
+func TestRegistrySuppressesRepeats(t *testing.T) {
+	tests := []struct {
+		name string
+		err  func() *Error
+		want int
+	}{
+		{"repeated error", func() *Error { return Network("patch server unreachable") }, 1},
+		{"critical", func() *Error { return GameCritical("failed to launch game") }, 3},
+		{"user initiated", func() *Error { return Game("failed to install game").ByUser() }, 3},
+		{"validation", func() *Error { return Validation("please enter a nickname") }, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &collect{}
+			r := &Registry{handlers: []Handler{c}, window: time.Hour, seen: make(map[string]*occurrence)}
+
+			for range 3 {
+				r.handle(tt.err())
+			}
+			if got := c.count(); got != tt.want {
+				t.Errorf("delivered %d of 3, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryFlushesRepeats(t *testing.T) {
+	c := &collect{}
+	r := &Registry{handlers: []Handler{c}, window: time.Hour, seen: make(map[string]*occurrence)}
+
+	for range 3 {
+		r.handle(Network("patch server unreachable"))
+	}
+	r.flush(Network("patch server unreachable").Fingerprint())
+
+	if got := c.count(); got != 2 {
+		t.Fatalf("delivered %d, want the first and one summary", got)
+	}
+	if n := c.errors[1].Occurrences; n != 2 {
+		t.Errorf("summary counts %d occurrences, want 2", n)
+	}
+}
+
+func TestAsyncQueueFull(t *testing.T) {
+	gate := make(chan struct{})
+	started := make(chan struct{}, 1)
+	c := &collect{}
+
+	// Holds the worker on the first error so the queue fills up
+	a := Async(HandlerFunc(func(err *Error) {
+		if !err.IsCritical() {
+			select {
+			case started <- struct{}{}:
+				<-gate
+			default:
+			}
+		}
+		c.Handle(err)
+	}), 1).(*asyncHandler)
+
+	a.Handle(Network("first"))
+	<-started
+	a.Handle(Network("queued"))
+	a.Handle(Network("dropped"))
+
+	// Handled right away on this goroutine, the worker is still stuck
+	a.Handle(GameCritical("failed to launch game"))
+	if got := c.count(); got != 1 {
+		t.Fatalf("critical error not handled while the queue was full, %d handled", got)
+	}
+	if n := a.dropped.Load(); n != 1 {
+		t.Errorf("%d errors counted as dropped, want 1", n)
+	}
+
+	close(gate)
+	deadline := time.Now().Add(5 * time.Second)
+	for c.count() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued errors not delivered, %d handled", c.count())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,244 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"HyLauncher/pkg/trace"
+)
+
+// FileName is the unified launcher log inside the logs directory
+const FileName = "launcher.log"
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+var std = struct {
+	mu        sync.Mutex
+	level     Level
+	retention Retention
+	file      *os.File
+	size      int64 // Bytes in file, to know when to rotate
+	dir       string
+	path      string
+}{level: LevelInfo, retention: DefaultRetention}
+
+// Init starts writing to FileName in dir next to stdout, which GUI builds
+// do not show
+func Init(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, FileName)
+	if err := RotateIfLarger(path); err != nil {
+		return err
+	}
+
+	f, size, err := openLog(path)
+	if err != nil {
+		return err
+	}
+
+	std.mu.Lock()
+	defer std.mu.Unlock()
+
+	if std.file != nil {
+		std.file.Close()
+	}
+	std.file = f
+	std.size = size
+	std.dir = dir
+	std.path = path
+
+	go compressLeftovers(dir)
+	return nil
+}
+
+func openLog(path string) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// rotateLocked moves the full log aside and starts a new one, std.mu is held.
+// Only the rename happens under the lock, compressing 10 MiB would stall
+// every goroutine that logs.
+func rotateLocked() {
+	std.file.Close()
+	std.file = nil
+
+	moved := rotatedName(std.path) + ".log"
+	if err := os.Rename(std.path, moved); err != nil {
+		fmt.Fprintf(os.Stderr, "rotate %s: %v\n", std.path, err)
+	} else {
+		go compressMoved(moved)
+	}
+
+	f, size, err := openLog(std.path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reopen %s: %v\n", std.path, err)
+		return
+	}
+	std.file = f
+	std.size = size
+}
+
+// Close stops writing to the log file
+func Close() error {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+
+	if std.file == nil {
+		return nil
+	}
+	err := std.file.Close()
+	std.file = nil
+	return err
+}
+
+// Path returns the log file, empty before Init
+func Path() string {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	return std.path
+}
+
+// SetLevel drops messages below level
+func SetLevel(level Level) {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	std.level = level
+}
+
+// SetDebug switches between debug and info verbosity
+func SetDebug(enabled bool) {
+	if enabled {
+		SetLevel(LevelDebug)
+	} else {
+		SetLevel(LevelInfo)
+	}
+}
+
+func enabled(level Level) bool {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	return level >= std.level
+}
+
+func write(level Level, subsystem, msg string) {
+	line := fmt.Sprintf("%s %-5s [%s] %s\n",
+		time.Now().Format("2006-01-02 15:04:05.000"),
+		level,
+		subsystem,
+		strings.TrimRight(msg, "\n"),
+	)
+
+	std.mu.Lock()
+	defer std.mu.Unlock()
+
+	if level < std.level {
+		return
+	}
+
+	var out io.Writer = os.Stdout
+	if level >= LevelWarn {
+		out = os.Stderr
+	}
+	io.WriteString(out, line)
+
+	if std.file != nil {
+		n, _ := std.file.WriteString(line)
+		std.size += int64(n)
+
+		if limit := std.retention.maxBytes(); limit > 0 && std.size >= limit {
+			rotateLocked()
+		}
+	}
+}
+
+// Logger writes messages of one subsystem, such as "download" or "game"
+type Logger struct {
+	subsystem string
+	span      *trace.Span
+}
+
+func New(subsystem string) *Logger {
+	return &Logger{subsystem: subsystem}
+}
+
+func (l *Logger) Debug(format string, args ...any) {
+	l.log(LevelDebug, format, args...)
+}
+
+func (l *Logger) Info(format string, args ...any) {
+	l.log(LevelInfo, format, args...)
+}
+
+func (l *Logger) Warn(format string, args ...any) {
+	l.log(LevelWarn, format, args...)
+}
+
+func (l *Logger) Error(format string, args ...any) {
+	l.log(LevelError, format, args...)
+}
+
+// Ctx returns a logger that tags its lines with the operation running in
+// ctx and records them in its trace
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	return &Logger{subsystem: l.subsystem, span: trace.FromContext(ctx)}
+}
+
+// Enabled reports whether messages at level are written, to skip building
+// expensive ones
+func (l *Logger) Enabled(level Level) bool {
+	return enabled(level)
+}
+
+func (l *Logger) log(level Level, format string, args ...any) {
+	if !enabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	tag := l.subsystem
+	if l.span != nil {
+		tag += " " + l.span.TraceID[:8]
+		l.span.Record("log", level.String()+" ["+l.subsystem+"] "+msg)
+	}
+	write(level, tag, msg)
+}
@@ -0,0 +1,231 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Retention controls how large logs grow and how long old ones are kept
+type Retention struct {
+	MaxSizeMB  int `toml:"max_size_mb" json:"maxSizeMB"`   // Rotate a log once it is larger, 0 never rotates
+	MaxFiles   int `toml:"max_files" json:"maxFiles"`      // Rotated copies kept per log, 0 keeps all
+	MaxAgeDays int `toml:"max_age_days" json:"maxAgeDays"` // Rotated logs and crash reports older than this are removed, 0 keeps them
+}
+
+// DefaultRetention keeps a month of logs, at most 5 rotated copies of 10 MiB each
+var DefaultRetention = Retention{MaxSizeMB: 10, MaxFiles: 5, MaxAgeDays: 30}
+
+const (
+	archiveSuffix     = ".log.gz"
+	archiveTimeFormat = "20060102-150405.000"
+	partialSuffix     = ".tmp" // Archive still being compressed, Prune ignores it
+)
+
+// Validate rejects negative limits
+func (r Retention) Validate() error {
+	if r.MaxSizeMB < 0 || r.MaxFiles < 0 || r.MaxAgeDays < 0 {
+		return errors.New("log limits cannot be negative")
+	}
+	return nil
+}
+
+func (r Retention) maxBytes() int64 {
+	return int64(r.MaxSizeMB) << 20
+}
+
+// MaxAge returns how long rotated logs are kept, 0 keeps them forever
+func (r Retention) MaxAge() time.Duration {
+	return time.Duration(r.MaxAgeDays) * 24 * time.Hour
+}
+
+// SetRetention changes the limits every log under the logs directory follows
+func SetRetention(r Retention) {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	std.retention = r
+}
+
+// CurrentRetention returns the limits set by SetRetention
+func CurrentRetention() Retention {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	return std.retention
+}
+
+// OpenFile opens name inside the logs directory for appending, rotating it
+// first when it has grown past the size limit. Used for logs written by
+// other processes, such as the game output.
+func OpenFile(name string) (*os.File, error) {
+	std.mu.Lock()
+	dir := std.dir
+	std.mu.Unlock()
+
+	if dir == "" {
+		return nil, os.ErrNotExist
+	}
+
+	path := filepath.Join(dir, name)
+	if err := RotateIfLarger(path); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+// RotateIfLarger rotates path when it exceeds the configured size
+func RotateIfLarger(path string) error {
+	limit := CurrentRetention().maxBytes()
+	if limit <= 0 {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Size() < limit {
+		return nil
+	}
+	return Rotate(path)
+}
+
+// Rotate compresses path next to it as <name>-<time>.log.gz and empties it
+func Rotate(path string) error {
+	if err := compress(path, rotatedName(path)+archiveSuffix); err != nil {
+		return err
+	}
+
+	// Truncate instead of removing, another process may still be appending
+	return os.Truncate(path, 0)
+}
+
+// rotatedName returns path as <name>-<time> without extension, the stem of
+// its rotated copies
+func rotatedName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return filepath.Join(filepath.Dir(path), base+"-"+time.Now().Format(archiveTimeFormat))
+}
+
+// compress gzips src into archive. It is written under a temporary name and
+// renamed when complete, so Prune never counts or removes a partial archive.
+func compress(src, archive string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := archive + partialSuffix
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, archive)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// compressMoved compresses a log rotateLocked moved aside as <name>-<time>.log
+// and removes it. It runs without std.mu, so logging goes on meanwhile.
+func compressMoved(moved string) {
+	archive := strings.TrimSuffix(moved, ".log") + archiveSuffix
+	if err := compress(moved, archive); err != nil {
+		fmt.Fprintf(os.Stderr, "compress %s: %v\n", moved, err)
+		return
+	}
+	os.Remove(moved)
+}
+
+// compressLeftovers finishes rotations a previous run stopped before they
+// were compressed
+func compressLeftovers(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		stem, ok := strings.CutSuffix(entry.Name(), ".log")
+		if !ok || entry.IsDir() || len(stem) <= len(archiveTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(archiveTimeFormat, stem[len(stem)-len(archiveTimeFormat):]); err != nil {
+			continue
+		}
+		compressMoved(filepath.Join(dir, entry.Name()))
+	}
+}
+
+// Prune removes rotated logs in dir that are older than the retention or
+// beyond the number of copies kept per log. Archives still being compressed
+// have a temporary name and are left alone.
+func Prune(dir string, r Retention) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	cutoff := time.Now().Add(-r.MaxAge())
+	groups := make(map[string][]string)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, archiveSuffix) {
+			continue
+		}
+
+		if r.MaxAgeDays > 0 {
+			info, err := entry.Info()
+			if err == nil && info.ModTime().Before(cutoff) {
+				os.Remove(filepath.Join(dir, name))
+				continue
+			}
+		}
+
+		stem := strings.TrimSuffix(name, archiveSuffix)
+		if len(stem) <= len(archiveTimeFormat) {
+			continue
+		}
+		base := stem[:len(stem)-len(archiveTimeFormat)-1]
+		groups[base] = append(groups[base], name)
+	}
+
+	if r.MaxFiles <= 0 {
+		return nil
+	}
+
+	for _, names := range groups {
+		// The timestamp in the name sorts oldest first
+		slices.Sort(names)
+		for _, name := range names[:max(len(names)-r.MaxFiles, 0)] {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+	return nil
+}
@@ -5,4 +5,5 @@ type InstanceModel struct {
 	InstanceName string
 	Branch       string
 	BuildVersion int
+	JREVersion   string
 }
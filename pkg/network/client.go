@@ -0,0 +1,78 @@
+package network
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// NewClient returns a client tuned for large downloads. It has no overall
+// timeout, requests are bounded by their context instead. Requests follow the
+// proxy set with SetProxy.
+func NewClient() *http.Client {
+	return newClient(currentProxy)
+}
+
+func newClient(proxy proxyFunc) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   60 * time.Second,
+		KeepAlive: 60 * time.Second,
+	}
+
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     false,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   20 * time.Second,
+		ExpectContinueTimeout: 2 * time.Second,
+		TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+		Proxy: proxy,
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}
+}
+
+var defaultClient atomic.Pointer[http.Client]
+
+func init() {
+	defaultClient.Store(NewClient())
+}
+
+// DefaultClient is used by every package that was not given its own client
+func DefaultClient() *http.Client {
+	return defaultClient.Load()
+}
+
+// SetDefaultClient replaces the client of every package that was not given
+// its own, nil restores a fresh one
+func SetDefaultClient(c *http.Client) {
+	if c == nil {
+		c = NewClient()
+	}
+	defaultClient.Store(c)
+}
+
+// ClientRef holds the client a package was given. The zero value falls back
+// to DefaultClient.
+type ClientRef struct {
+	client atomic.Pointer[http.Client]
+}
+
+// Set replaces the client, nil restores the default
+func (r *ClientRef) Set(c *http.Client) {
+	r.client.Store(c)
+}
+
+func (r *ClientRef) Get() *http.Client {
+	if c := r.client.Load(); c != nil {
+		return c
+	}
+	return DefaultClient()
+}
@@ -0,0 +1,209 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// HTTPStatusError is a response with a status the caller can not use
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	URL        string
+	RetryAfter time.Duration // From the Retry-After header, 0 when absent
+}
+
+// NewHTTPStatusError describes resp, the body is left to the caller
+func NewHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	e := &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+	if resp.Request != nil {
+		e.URL = resp.Request.URL.String()
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
+
+func (e *HTTPStatusError) Error() string {
+	status := e.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.URL == "" {
+		return "bad HTTP status: " + status
+	}
+	return fmt.Sprintf("bad HTTP status %s for %s", status, e.URL)
+}
+
+// Permanent reports a client error that asking again will not fix. Timeouts
+// and rate limits are 4xx too, but they pass, and so does a refused range,
+// which is about the partial file rather than the remote one.
+func (e *HTTPStatusError) Permanent() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+		http.StatusRequestedRangeNotSatisfiable:
+		return false
+	}
+	return e.StatusCode >= 400 && e.StatusCode < 500
+}
+
+// Hint tells the user what they can do about the error
+func (e *HTTPStatusError) Hint() string {
+	switch {
+	case e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone:
+		return "The file is no longer on the server. Check for a launcher update."
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return "The server refused access. A proxy, VPN or firewall may be blocking it."
+	case e.StatusCode == http.StatusProxyAuthRequired:
+		return "The proxy rejected the login. Check the proxy username and password."
+	case e.StatusCode == http.StatusTooManyRequests:
+		return "Too many requests. Wait a few minutes and try again."
+	case e.StatusCode >= 500:
+		return "The server is having problems. Try again later."
+	default:
+		return "The server rejected the request. Check for a launcher update."
+	}
+}
+
+// Fields returns what a report needs to know about the response
+func (e *HTTPStatusError) Fields() map[string]any {
+	return map[string]any{"status": e.StatusCode, "url": e.URL}
+}
+
+// ErrorKind says at which layer a request failed
+type ErrorKind string
+
+const (
+	KindDNS        ErrorKind = "dns"
+	KindTLS        ErrorKind = "tls"
+	KindTimeout    ErrorKind = "timeout"
+	KindConnection ErrorKind = "connection"
+	KindProxy      ErrorKind = "proxy"
+)
+
+// Error is a request that failed before a usable response arrived
+type Error struct {
+	Kind ErrorKind
+	URL  string
+	Err  error
+}
+
+// Classify turns a transport error into an *Error. Cancellation and errors
+// it does not recognise are returned as they are.
+func Classify(url string, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	var typed *Error
+	if errors.As(err, &typed) {
+		return err
+	}
+
+	kind, ok := kindOf(err)
+	if !ok {
+		return err
+	}
+	return &Error{Kind: kind, URL: url, Err: err}
+}
+
+func kindOf(err error) (ErrorKind, bool) {
+	var (
+		dnsErr     *net.DNSError
+		certErr    *tls.CertificateVerificationError
+		authErr    x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+		recordErr  tls.RecordHeaderError
+		alertErr   tls.AlertError
+		netErr     net.Error
+		opErr      *net.OpError
+	)
+
+	switch {
+	case errors.As(err, &opErr) && (opErr.Op == "proxyconnect" || strings.HasPrefix(opErr.Op, "socks")):
+		return KindProxy, true
+	case errors.As(err, &dnsErr):
+		return KindDNS, true
+	case errors.As(err, &certErr), errors.As(err, &authErr), errors.As(err, &hostErr),
+		errors.As(err, &invalidErr), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return KindTLS, true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return KindTimeout, true
+	case errors.As(err, &opErr),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return KindConnection, true
+	}
+	return "", false
+}
+
+// Error leaves the URL out, the transport errors of net/http already name it
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s error: %v", e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Timeout matches net.Error
+func (e *Error) Timeout() bool {
+	return e.Kind == KindTimeout
+}
+
+// Permanent reports a certificate problem, which a retry will not fix. A
+// failed lookup may just be a dropped connection, so it is retried.
+func (e *Error) Permanent() bool {
+	if e.Kind != KindTLS {
+		return false
+	}
+
+	var recordErr tls.RecordHeaderError
+	return !errors.As(e.Err, &recordErr)
+}
+
+// Hint tells the user what they can do about the error
+func (e *Error) Hint() string {
+	switch e.Kind {
+	case KindDNS:
+		return "The server name could not be resolved. Check your internet connection or DNS settings."
+	case KindTLS:
+		return "The secure connection failed. Check the system clock, and any antivirus or proxy that inspects HTTPS."
+	case KindTimeout:
+		return "The server took too long to answer. Check your connection or try again later."
+	case KindProxy:
+		return "The proxy could not be reached or refused the connection. Check the proxy settings."
+	default:
+		return "The connection to the server failed. Check your internet connection and firewall."
+	}
+}
+
+// Fields returns what a report needs to know about the failure
+func (e *Error) Fields() map[string]any {
+	return map[string]any{"network_error": string(e.Kind), "url": e.URL}
+}
+
+// IsError reports whether err comes from the network rather than from the
+// launcher itself
+func IsError(err error) bool {
+	var statusErr *HTTPStatusError
+	var netErr *Error
+	return errors.As(err, &statusErr) || errors.As(err, &netErr)
+}
@@ -7,17 +7,22 @@ import (
 )
 
 func TestConnection(testURL string) error {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
+	return TestConnectionWith(DefaultClient(), testURL)
+}
+
+// TestConnectionWith is TestConnection through the given client
+func TestConnectionWith(c *http.Client, testURL string) error {
+	client := *c
+	client.Timeout = 5 * time.Second
 
 	resp, err := client.Head(testURL)
 	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
+		return fmt.Errorf("cannot reach server: %w", Classify(testURL, err))
 	}
+	resp.Body.Close()
 
 	if resp.StatusCode >= 500 {
-		return fmt.Errorf("server error (HTTP %d)", resp.StatusCode)
+		return NewHTTPStatusError(resp)
 	}
 
 	return nil
@@ -0,0 +1,172 @@
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+type ProxyMode string
+
+const (
+	ProxySystem ProxyMode = "system" // HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment
+	ProxyNone   ProxyMode = "none"
+	ProxyManual ProxyMode = "manual"
+)
+
+// ProxyConfig is the proxy the launcher sends its requests through
+type ProxyConfig struct {
+	Mode     ProxyMode `toml:"mode" json:"mode"`
+	HTTP     string    `toml:"http" json:"http"`         // host:port or URL, also used for HTTPS when that is empty
+	HTTPS    string    `toml:"https" json:"https"`       // host:port or URL
+	SOCKS5   string    `toml:"socks5" json:"socks5"`     // host:port, replaces HTTP and HTTPS when set
+	Username string    `toml:"username" json:"username"` // Sent to every proxy above
+	Password string    `toml:"password" json:"password"`
+	NoProxy  string    `toml:"no_proxy" json:"noProxy"` // Comma separated hosts, domains and CIDRs reached directly
+}
+
+// redacted replaces secrets in configs shared with support
+const redacted = "[redacted]"
+
+type proxyFunc func(*http.Request) (*url.URL, error)
+
+var (
+	proxyMu sync.RWMutex
+	proxy   proxyFunc = http.ProxyFromEnvironment
+)
+
+// SetProxy routes the requests of every client made by NewClient through cfg
+func SetProxy(cfg ProxyConfig) error {
+	fn, err := cfg.proxyFunc()
+	if err != nil {
+		return err
+	}
+
+	proxyMu.Lock()
+	proxy = fn
+	proxyMu.Unlock()
+
+	// Pooled connections still lead to the old proxy
+	DefaultClient().CloseIdleConnections()
+	return nil
+}
+
+func currentProxy(req *http.Request) (*url.URL, error) {
+	proxyMu.RLock()
+	fn := proxy
+	proxyMu.RUnlock()
+
+	if fn == nil {
+		return nil, nil
+	}
+	return fn(req)
+}
+
+// NewProxyClient returns a client that uses cfg instead of the proxy set with
+// SetProxy, to try settings before they are saved
+func NewProxyClient(cfg ProxyConfig) (*http.Client, error) {
+	fn, err := cfg.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	return newClient(fn), nil
+}
+
+// Validate checks the proxy addresses
+func (p ProxyConfig) Validate() error {
+	_, err := p.proxyFunc()
+	return err
+}
+
+func (p ProxyConfig) proxyFunc() (proxyFunc, error) {
+	switch p.Mode {
+	case ProxySystem, "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyNone:
+		return nil, nil
+	case ProxyManual:
+	default:
+		return nil, fmt.Errorf("unknown proxy mode %q", p.Mode)
+	}
+
+	httpURL, err := p.proxyURL(p.HTTP, "http")
+	if err != nil {
+		return nil, err
+	}
+	httpsURL, err := p.proxyURL(p.HTTPS, "http")
+	if err != nil {
+		return nil, err
+	}
+	socksURL, err := p.proxyURL(p.SOCKS5, "socks5")
+	if err != nil {
+		return nil, err
+	}
+
+	if httpsURL == "" {
+		httpsURL = httpURL
+	}
+	if socksURL != "" {
+		httpURL, httpsURL = socksURL, socksURL
+	}
+	if httpURL == "" && httpsURL == "" {
+		return nil, fmt.Errorf("manual proxy needs an HTTP, HTTPS or SOCKS5 address")
+	}
+
+	cfg := httpproxy.Config{
+		HTTPProxy:  httpURL,
+		HTTPSProxy: httpsURL,
+		NoProxy:    p.NoProxy,
+	}
+	forURL := cfg.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return forURL(req.URL)
+	}, nil
+}
+
+// Redacted returns p without its password, for sharing in support bundles
+func (p ProxyConfig) Redacted() ProxyConfig {
+	if p.Password != "" {
+		p.Password = redacted
+	}
+	for _, addr := range []*string{&p.HTTP, &p.HTTPS, &p.SOCKS5} {
+		if u, err := url.Parse(*addr); err == nil && u.User != nil {
+			*addr = u.Redacted()
+		}
+	}
+	return p
+}
+
+// proxyURL completes addr to a URL with the credentials, scheme is used when
+// addr has none
+func (p ProxyConfig) proxyURL(addr, scheme string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", nil
+	}
+	if !strings.Contains(addr, "://") {
+		addr = scheme + "://" + addr
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid proxy address %q: %w", addr, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return "", fmt.Errorf("unsupported proxy scheme %q, use http, https or socks5", u.Scheme)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return "", fmt.Errorf("invalid proxy address %q, expected host:port", addr)
+	}
+
+	if p.Username != "" {
+		u.User = url.UserPassword(p.Username, p.Password)
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,87 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy decides how often and how long to wait before a failed request
+// is tried again
+type RetryPolicy struct {
+	Attempts  int           // Including the first one
+	BaseDelay time.Duration // Doubles after every attempt
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy is used for downloads and API calls
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:  5,
+	BaseDelay: 3 * time.Second,
+	MaxDelay:  60 * time.Second,
+}
+
+// Retryable reports whether trying again can help. Permanent 4xx responses and
+// certificate errors never do, neither does cancellation.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var permanent interface{ Permanent() bool }
+	if errors.As(err, &permanent) {
+		return !permanent.Permanent()
+	}
+	return true
+}
+
+// Delay returns the wait before attempt, counting from 1. A Retry-After the
+// server sent in err is honoured up to MaxDelay.
+func (p RetryPolicy) Delay(attempt int, err error) time.Duration {
+	if attempt <= 1 {
+		return 0
+	}
+
+	delay := p.BaseDelay << (attempt - 2)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		delay = min(statusErr.RetryAfter, p.MaxDelay)
+	}
+	return delay
+}
+
+// Wait sleeps before attempt, returning early with the error of ctx
+func (p RetryPolicy) Wait(ctx context.Context, attempt int, err error) error {
+	timer := time.NewTimer(p.Delay(attempt, err))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Do runs fn until it succeeds, fails with an error that is not Retryable or
+// runs out of attempts
+func (p RetryPolicy) Do(ctx context.Context, fn func(attempt int) error) error {
+	var err error
+	for attempt := 1; attempt <= p.Attempts; attempt++ {
+		if attempt > 1 {
+			if werr := p.Wait(ctx, attempt, err); werr != nil {
+				return werr
+			}
+		}
+
+		err = fn(attempt)
+		if err == nil || ctx.Err() != nil || !Retryable(err) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	maxTraces = 16  // Operations kept in memory, the oldest are forgotten
+	maxEvents = 500 // Events kept per operation, the oldest are dropped
+)
+
+// Span is one step of an operation. An operation such as "download and
+// launch" is a tree of spans carried in a context.Context, log lines,
+// progress updates and errors made under it are recorded with its trace ID
+// so a crash report can show everything that led to a failure.
+type Span struct {
+	TraceID  string
+	ID       string
+	ParentID string
+	Name     string
+	Start    time.Time
+}
+
+// Event is something that happened during an operation
+type Event struct {
+	Time    time.Time `json:"time"`
+	SpanID  string    `json:"span_id"`
+	Kind    string    `json:"kind"` // span_start, span_end, log, progress or error
+	Message string    `json:"message"`
+}
+
+type spanKey struct{}
+
+// NewID returns 16 random hex characters, unlike a timestamp two IDs made at
+// the same moment do not collide
+func NewID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand does not fail on supported platforms
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Start begins a span named name. Inside another span it becomes its child,
+// otherwise it starts a new operation.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{ID: NewID(), Name: name, Start: time.Now()}
+
+	if parent := FromContext(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.ID
+	} else {
+		span.TraceID = span.ID
+	}
+
+	span.Record("span_start", name)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span ctx was started under, nil outside any
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// End records how long the span took and the error it ended with, if any
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("%s took %s", s.Name, time.Since(s.Start).Round(time.Millisecond))
+	if err != nil {
+		msg += ": " + err.Error()
+	}
+	s.Record("span_end", msg)
+}
+
+// Record adds an event to the span's operation
+func (s *Span) Record(kind, message string) {
+	if s == nil {
+		return
+	}
+	recorder.add(s.TraceID, Event{
+		Time:    time.Now(),
+		SpanID:  s.ID,
+		Kind:    kind,
+		Message: message,
+	})
+}
+
+// Record adds an event to the operation of ctx, outside any it does nothing
+func Record(ctx context.Context, kind, message string) {
+	FromContext(ctx).Record(kind, message)
+}
+
+// Events returns what was recorded for the operation traceID, oldest first
+func Events(traceID string) []Event {
+	return recorder.events(traceID)
+}
+
+var recorder = &store{traces: make(map[string][]Event)}
+
+type store struct {
+	mu     sync.Mutex
+	traces map[string][]Event
+	order  []string // Trace IDs, oldest first
+}
+
+func (s *store) add(traceID string, event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, ok := s.traces[traceID]
+	if !ok {
+		s.order = append(s.order, traceID)
+		if len(s.order) > maxTraces {
+			delete(s.traces, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+
+	events = append(events, event)
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+	s.traces[traceID] = events
+}
+
+func (s *store) events(traceID string) []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.traces[traceID]...)
+}